@@ -2,29 +2,599 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"regexp"
 	"slices"
+	"syscall"
+	"text/tabwriter"
+	"text/template"
+	"time"
 
+	"github.com/go-orb/go-orb/codecs"
+	"github.com/go-orb/go-orb/log"
 	"github.com/urfave/cli/v3"
 
 	"github.com/octocompose/operator-docker/pkg/operatorbase"
 )
 
+// registryFlags returns the flags of RegistryLogin.
+func registryFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "registry",
+			Usage: "Log in to this registry first.",
+		},
+		&cli.StringFlag{
+			Name:  "registry-user-env",
+			Usage: "Name of the environment variable holding the registry user.",
+		},
+		&cli.StringFlag{
+			Name:  "registry-pass-env",
+			Usage: "Name of the environment variable holding the registry password.",
+		},
+		&cli.DurationFlag{
+			Name:  "registry-login-timeout",
+			Value: 30 * time.Second,
+			Usage: "Give up a registry login attempt after this long.",
+		},
+		&cli.IntFlag{
+			Name:  "registry-login-retries",
+			Value: 2,
+			Usage: "Retry a failed registry login this many times.",
+		},
+	}
+}
+
 var startCmd = &cli.Command{
 	Name:  "start",
 	Usage: "run docker compose up -d",
+	Flags: append(registryFlags(),
+		&cli.BoolFlag{
+			Name: "dry-run",
+		},
+		&cli.StringSliceFlag{
+			Name:  "insecure-registry",
+			Usage: "Warn when the docker daemon doesn't treat this registry (host[:port]) as insecure, may be repeated.",
+		},
+		&cli.BoolFlag{
+			Name:  "plan",
+			Usage: "Show which services would be created, recreated or orphaned and exit.",
+		},
+		&cli.BoolFlag{
+			Name:  "verify-images",
+			Usage: "Check that the image of every service exists in its registry before starting.",
+		},
+		&cli.BoolFlag{
+			Name:  "recreate-on-config-change",
+			Usage: "Only recreate containers when the rendered compose file changed since the last deploy.",
+		},
+		&cli.BoolFlag{
+			Name:  "foreground",
+			Usage: "Run docker compose up without -d.",
+		},
+		&cli.BoolFlag{
+			Name:  "attach",
+			Usage: "Run docker compose up in the foreground and tear down the project on exit or Ctrl-C.",
+		},
+		&cli.BoolFlag{
+			Name:  "detach-after-healthy",
+			Usage: "Stream the logs after docker compose up -d until every service is healthy, then return.",
+		},
+		&cli.DurationFlag{
+			Name:  "healthy-timeout",
+			Value: 5 * time.Minute,
+			Usage: "Fail --detach-after-healthy when the services aren't healthy after this long.",
+		},
+		&cli.BoolFlag{
+			Name:  "abort-on-container-exit",
+			Usage: "Stop all containers if any container exits, requires --foreground.",
+		},
+		&cli.StringFlag{
+			Name:  "exit-code-from",
+			Usage: "Return the exit code of this service's container, requires --foreground.",
+		},
+		&cli.BoolFlag{
+			Name:  "attach-dependencies",
+			Usage: "Also stream the logs of the dependencies of the started services, requires --foreground or --attach.",
+		},
+		&cli.StringSliceFlag{
+			Name:  "no-attach",
+			Usage: "Don't stream the logs of this service, requires --foreground or --attach, may be repeated.",
+		},
+		&cli.BoolFlag{
+			Name:  "quiet-pull",
+			Usage: "Pull without printing progress information.",
+		},
+		&cli.StringFlag{
+			Name:  "pull",
+			Usage: "Pull images before starting (always, missing, never), defaults to the behavior of the compose backend.",
+		},
+		&cli.BoolFlag{
+			Name:  "override-stdin",
+			Usage: "Read a compose override fragment from stdin.",
+		},
+		&cli.StringSliceFlag{
+			Name:  "set",
+			Usage: "Set an interpolation variable (KEY=VALUE) for docker compose, may be repeated.",
+		},
+		&cli.IntFlag{
+			Name:  "up-retries",
+			Usage: "Re-run docker compose up this many times when it fails.",
+		},
+		&cli.DurationFlag{
+			Name:  "up-retry-delay",
+			Value: 5 * time.Second,
+			Usage: "Wait this long between docker compose up retries.",
+		},
+		&cli.BoolFlag{
+			Name:  "build",
+			Usage: "Build the images before starting the containers.",
+		},
+		&cli.StringSliceFlag{
+			Name:  "only",
+			Usage: "Start only this service and its dependencies, may be repeated.",
+		},
+		&cli.BoolFlag{
+			Name:  "parallel-services",
+			Usage: "Start the services in tiers ordered by depends_on, the services of a tier in parallel.",
+		},
+		&cli.BoolFlag{
+			Name:  "no-recreate-deps",
+			Usage: "Start the dependencies of the --only services without recreating the running ones.",
+		},
+		&cli.StringSliceFlag{
+			Name:  "build-arg",
+			Usage: "Set a build-time variable (KEY=VALUE), may be repeated.",
+		},
+		&cli.StringFlag{
+			Name:  "build-target",
+			Usage: "Build this stage of every service with a build section.",
+		},
+		&cli.BoolFlag{
+			Name:  "buildkit",
+			Value: true,
+			Usage: "Build with BuildKit, also set by octoctl.buildkit.",
+		},
+		&cli.StringFlag{
+			Name:  "on-failure",
+			Value: "leave",
+			Usage: "What to do with the started containers when the start fails (leave, down, rollback).",
+		},
+	),
+	Before: operatorbase.BeforeConfig([]string{"docker", "compose"}),
+	Action: operatorbase.FanOut(startAction),
+}
+
+// startAction runs docker compose up for the start command.
+func startAction(ctx context.Context, cmd *cli.Command) error {
+	variables, err := operatorbase.ParseKeyValues(cmd.StringSlice("set"))
+	if err != nil {
+		return err
+	}
+
+	ctx = operatorbase.WithEnv(ctx, variables)
+
+	// A plan has no side effects, it runs before the registry login and the override.
+	if cmd.Bool("plan") {
+		if cmd.Bool("override-stdin") {
+			return errors.New("--plan can't be combined with --override-stdin")
+		}
+
+		plan, err := operatorbase.PlanDeploy(ctx)
+		if err != nil {
+			return err
+		}
+
+		printPlan(operatorbase.Stdout(ctx), plan)
+
+		return nil
+	}
+
+	operatorbase.CheckInsecureRegistries(ctx, cmd.StringSlice("insecure-registry"))
+
+	if err := operatorbase.RegistryLogin(ctx, cmd); err != nil {
+		return err
+	}
+
+	if cmd.Bool("verify-images") {
+		if err := verifyImages(ctx); err != nil {
+			return err
+		}
+	}
+
+	if cmd.Bool("override-stdin") {
+		if cmd.String("config") == "-" {
+			return errors.New("--override-stdin can't be used when reading the config from stdin")
+		}
+
+		ctx, err = operatorbase.WithStdinOverride(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := operatorbase.ValidateOnFailure(cmd.String("on-failure")); err != nil {
+		return err
+	}
+
+	if !cmd.Bool("build") && (len(cmd.StringSlice("build-arg")) > 0 || cmd.String("build-target") != "") {
+		return errors.New("--build-arg and --build-target require --build")
+	}
+
+	if cmd.Bool("build") {
+		ctx, err = buildImages(ctx, cmd)
+		if err != nil {
+			return err
+		}
+	}
+
+	args := []string{"up"}
+
+	if cmd.Bool("detach-after-healthy") && (cmd.Bool("foreground") || cmd.Bool("attach")) {
+		return errors.New("--detach-after-healthy can't be combined with --foreground or --attach")
+	}
+
+	if cmd.Bool("foreground") || cmd.Bool("attach") {
+		if cmd.Bool("abort-on-container-exit") {
+			args = append(args, "--abort-on-container-exit")
+		}
+
+		if cmd.String("exit-code-from") != "" {
+			args = append(args, "--exit-code-from", cmd.String("exit-code-from"))
+		}
+
+		if cmd.Bool("attach-dependencies") {
+			args = append(args, "--attach-dependencies")
+		}
+
+		if noAttach := cmd.StringSlice("no-attach"); len(noAttach) > 0 {
+			if err := operatorbase.CheckServiceNames(operatorbase.ComposeFilePath(ctx), noAttach); err != nil {
+				return err
+			}
+
+			for _, service := range noAttach {
+				args = append(args, "--no-attach", service)
+			}
+		}
+	} else {
+		if cmd.Bool("abort-on-container-exit") || cmd.String("exit-code-from") != "" || len(cmd.StringSlice("no-attach")) > 0 {
+			return errors.New("--abort-on-container-exit, --exit-code-from and --no-attach require --foreground or --attach")
+		}
+
+		if cmd.Bool("attach-dependencies") {
+			logger := ctx.Value(operatorbase.LoggerKey{}).(log.Logger)
+			logger.Warn("Ignoring --attach-dependencies, it requires --foreground or --attach")
+		}
+
+		args = append(args, "-d")
+	}
+
+	if cmd.Bool("quiet-pull") {
+		args = append(args, "--quiet-pull")
+	}
+
+	if pull := cmd.String("pull"); pull != "" {
+		if err := operatorbase.ValidatePullPolicy(pull); err != nil {
+			return err
+		}
+
+		args = append(args, "--pull", pull)
+	}
+
+	services := cmd.StringSlice("only")
+
+	if cmd.Bool("no-recreate-deps") {
+		if len(services) == 0 {
+			return errors.New("--no-recreate-deps requires --only")
+		}
+
+		if err := startDependencies(ctx, services, cmd.Bool("dry-run")); err != nil {
+			return err
+		}
+
+		args = append(args, "--no-deps")
+	}
+
+	if cmd.Bool("dry-run") {
+		return operatorbase.RunCompose(ctx, append(append(args, "--dry-run"), services...))
+	}
+
+	if cmd.Bool("attach") {
+		return runAttached(ctx, append(args, services...))
+	}
+
+	retries := int(cmd.Int("up-retries"))
+	if retries < 0 {
+		return errors.New("--up-retries must not be negative")
+	}
+
+	composeFilePath := operatorbase.ComposeFilePath(ctx)
+
+	if cmd.Bool("recreate-on-config-change") {
+		hash, err := operatorbase.FileHash(composeFilePath)
+		if err != nil {
+			return err
+		}
+
+		lastHash, err := operatorbase.LastDeployHash(composeFilePath)
+		if err != nil {
+			return err
+		}
+
+		if hash == lastHash {
+			args = append(args, "--no-recreate")
+		}
+	}
+
+	// Point out self-signed registry certificates instead of leaving the user with an x509 error.
+	tlsErrorWriter := operatorbase.NewTLSErrorWriter(os.Stderr)
+	ctx = context.WithValue(ctx, operatorbase.StderrKey{}, tlsErrorWriter)
+
+	tiers := [][]string{services}
+
+	if cmd.Bool("parallel-services") {
+		if len(services) > 0 {
+			return errors.New("--parallel-services can't be combined with --only")
+		}
+
+		tiers, err = startTiers(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	startedAt := time.Now()
+
+	for _, tier := range tiers {
+		tierArgs := append(append([]string{}, args...), tier...)
+
+		if err := operatorbase.RunComposeRetry(ctx, tierArgs, retries, cmd.Duration("up-retry-delay")); err != nil {
+			err = tlsErrorWriter.Wrap(err, cmd.StringSlice("insecure-registry"))
+			return handleStartFailure(ctx, cmd.String("on-failure"), false, err)
+		}
+	}
+
+	// A partial deploy with --only must not mark the other services as up to date.
+	recorded := len(services) == 0
+	if recorded {
+		if err := operatorbase.RecordDeploy(composeFilePath); err != nil {
+			return err
+		}
+	}
+
+	if cmd.Bool("detach-after-healthy") {
+		if err := followUntilHealthy(ctx, startedAt, cmd.Duration("healthy-timeout")); err != nil {
+			return handleStartFailure(ctx, cmd.String("on-failure"), recorded, err)
+		}
+	}
+
+	return nil
+}
+
+// handleStartFailure applies the on-failure policy after the start failed with err,
+// recorded tells whether the failed version has already been stored in the history.
+func handleStartFailure(ctx context.Context, policy string, recorded bool, err error) error {
+	logger := ctx.Value(operatorbase.LoggerKey{}).(log.Logger)
+
+	switch policy {
+	case "down":
+		logger.Warn("Start failed, tearing down", "error", err)
+
+		if downErr := operatorbase.RunCompose(context.WithoutCancel(ctx), []string{"down"}); downErr != nil {
+			return errors.Join(err, downErr)
+		}
+	case "rollback":
+		// The failed version is the deployed one once it's recorded, else the last good deploy is.
+		lastGood := operatorbase.CurrentHistory
+		if recorded {
+			lastGood = operatorbase.PreviousHistory
+		}
+
+		target, ok, histErr := lastGood(operatorbase.ComposeFilePath(ctx))
+		if histErr != nil {
+			return errors.Join(err, histErr)
+		}
+
+		if !ok {
+			logger.Warn("Start failed, no previous version to roll back to", "error", err)
+			return err
+		}
+
+		logger.Warn("Start failed, rolling back", "version", target.Timestamp, "error", err)
+
+		if rollbackErr := rollbackTo(context.WithoutCancel(ctx), target); rollbackErr != nil {
+			return errors.Join(err, rollbackErr)
+		}
+	}
+
+	return err
+}
+
+// followUntilHealthy streams the logs of the project since the given time until every
+// service is running and healthy, the containers keep running when it returns.
+func followUntilHealthy(ctx context.Context, since time.Time, timeout time.Duration) error {
+	logger := ctx.Value(operatorbase.LoggerKey{}).(log.Logger)
+
+	logsCtx, stopLogs := context.WithCancel(ctx)
+	defer stopLogs()
+
+	logsDone := make(chan struct{})
+
+	go func() {
+		defer close(logsDone)
+
+		// The logs end with the cancel, their error doesn't matter.
+		_ = operatorbase.RunCompose(logsCtx, []string{"logs", "--follow", "--since", since.Format(time.RFC3339)})
+	}()
+
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	deadline := time.After(timeout)
+
+	var unhealthy []operatorbase.UnhealthyService
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline:
+			stopLogs()
+			<-logsDone
+
+			for _, svc := range unhealthy {
+				logger.Error("Service is not healthy", "service", svc.Service, "reason", svc.Reason)
+			}
+
+			return fmt.Errorf("the services weren't healthy after %s", timeout)
+		case <-ticker.C:
+			var err error
+
+			unhealthy, err = operatorbase.CheckServices(ctx)
+			if err != nil {
+				return err
+			}
+
+			if len(unhealthy) == 0 {
+				stopLogs()
+				<-logsDone
+
+				logger.Info("All services are healthy, detaching")
+
+				return nil
+			}
+		}
+	}
+}
+
+// startTiers returns the services of the rendered compose file in dependency tiers,
+// or a single tier with all services when the dependencies have a cycle.
+func startTiers(ctx context.Context) ([][]string, error) {
+	logger := ctx.Value(operatorbase.LoggerKey{}).(log.Logger)
+
+	data, err := operatorbase.LoadComposeFile(operatorbase.ComposeFilePath(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	services, _ := data["services"].(map[string]any) //nolint:errcheck
+
+	tiers, err := operatorbase.DependencyTiers(services)
+	if err != nil {
+		logger.Warn("Starting all services at once", "error", err)
+		return [][]string{nil}, nil
+	}
+
+	logger.Debug("Starting in tiers", "tiers", tiers)
+
+	return tiers, nil
+}
+
+// startDependencies starts the dependencies of services which aren't running
+// without recreating the running ones.
+func startDependencies(ctx context.Context, services []string, dryRun bool) error {
+	deps, err := operatorbase.ServiceDependencies(operatorbase.ComposeFilePath(ctx), services)
+	if err != nil {
+		return err
+	}
+
+	if len(deps) == 0 {
+		return nil
+	}
+
+	args := []string{"up", "-d", "--no-recreate"}
+	if dryRun {
+		args = append(args, "--dry-run")
+	}
+
+	return operatorbase.RunCompose(ctx, append(args, deps...))
+}
+
+// runAttached runs docker compose up in the foreground until it exits or
+// the user interrupts it, then tears the project down.
+func runAttached(ctx context.Context, args []string) error {
+	logger := ctx.Value(operatorbase.LoggerKey{}).(log.Logger)
+
+	sigCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err := operatorbase.RunCompose(sigCtx, args)
+	interrupted := sigCtx.Err() != nil
+
+	logger.Info("Tearing down")
+
+	if downErr := operatorbase.RunCompose(context.WithoutCancel(ctx), []string{"down"}); downErr != nil {
+		return downErr
+	}
+
+	if interrupted {
+		return nil
+	}
+
+	return err
+}
+
+var buildCmd = &cli.Command{
+	Name:      "build",
+	Usage:     "run docker compose build",
+	ArgsUsage: "[service...]",
 	Flags: []cli.Flag{
 		&cli.BoolFlag{
 			Name: "dry-run",
 		},
+		&cli.StringSliceFlag{
+			Name:  "build-arg",
+			Usage: "Set a build-time variable (KEY=VALUE), may be repeated.",
+		},
+		&cli.StringFlag{
+			Name:  "build-target",
+			Usage: "Build this stage of every service with a build section.",
+		},
+		&cli.BoolFlag{
+			Name:  "buildkit",
+			Value: true,
+			Usage: "Build with BuildKit, also set by octoctl.buildkit.",
+		},
 	},
 	Before: operatorbase.BeforeConfig([]string{"docker", "compose"}),
-	Action: func(ctx context.Context, cmd *cli.Command) error {
-		if cmd.Bool("dry-run") {
-			return operatorbase.RunCompose(ctx, []string{"up", "-d", "--dry-run"})
+	Action: operatorbase.FanOut(func(ctx context.Context, cmd *cli.Command) error {
+		_, err := buildImages(ctx, cmd)
+		return err
+	}),
+}
+
+// buildImages runs docker compose build with the --build-arg and --build-target flags of cmd,
+// it returns a context which keeps the build target override for later compose calls.
+func buildImages(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+	args := []string{"build"}
+
+	buildArgs, err := operatorbase.BuildArgs(cmd.StringSlice("build-arg"))
+	if err != nil {
+		return ctx, err
+	}
+
+	args = append(args, buildArgs...)
+
+	if target := cmd.String("build-target"); target != "" {
+		ctx, err = operatorbase.WithBuildTarget(ctx, target)
+		if err != nil {
+			return ctx, err
 		}
+	}
 
-		return operatorbase.RunCompose(ctx, []string{"up", "-d"})
-	},
+	if cmd.Bool("dry-run") {
+		args = append(args, "--dry-run")
+	}
+
+	if cmd.Name == "build" {
+		args = append(args, cmd.Args().Slice()...)
+	}
+
+	return ctx, operatorbase.RunCompose(ctx, args)
 }
 
 var stopCmd = &cli.Command{
@@ -34,33 +604,97 @@ var stopCmd = &cli.Command{
 		&cli.BoolFlag{
 			Name: "dry-run",
 		},
+		&cli.StringFlag{
+			Name:  "rmi",
+			Usage: "Remove images used by services (local, all).",
+		},
+		&cli.BoolFlag{
+			Name:  "purge",
+			Usage: "Remove the project's cache directory after a successful down.",
+		},
+		&cli.BoolFlag{
+			Name:  "all-resources",
+			Usage: "Also remove the resources declared in the config which no service uses.",
+		},
 	},
 	Before: operatorbase.BeforeConfig([]string{"docker", "compose"}),
-	Action: func(ctx context.Context, cmd *cli.Command) error {
+	Action: operatorbase.FanOut(func(ctx context.Context, cmd *cli.Command) error {
+		args := []string{"down"}
+
+		if rmi := cmd.String("rmi"); rmi != "" {
+			if rmi != "local" && rmi != "all" {
+				return fmt.Errorf("invalid --rmi value '%s', expected local or all", rmi)
+			}
+
+			args = append(args, "--rmi", rmi)
+		}
+
+		if cmd.Bool("all-resources") {
+			args = append(args, "--all-resources")
+		}
+
 		if cmd.Bool("dry-run") {
-			return operatorbase.RunCompose(ctx, []string{"down", "--dry-run"})
+			return operatorbase.RunCompose(ctx, append(args, "--dry-run"))
 		}
 
-		return operatorbase.RunCompose(ctx, []string{"down"})
-	},
+		if err := operatorbase.RunCompose(ctx, args); err != nil {
+			return err
+		}
+
+		if cmd.Bool("purge") {
+			return operatorbase.PurgeProject(operatorbase.ComposeFilePath(ctx))
+		}
+
+		return nil
+	}),
 }
 
 var restartCmd = &cli.Command{
-	Name:  "restart",
-	Usage: "run docker compose restart",
+	Name:      "restart",
+	Usage:     "run docker compose restart",
+	ArgsUsage: "[service...]",
 	Flags: []cli.Flag{
 		&cli.BoolFlag{
 			Name: "dry-run",
 		},
+		&cli.BoolFlag{
+			Name:  "rolling",
+			Usage: "Restart the containers one at a time, waiting for each to be healthy.",
+		},
+		&cli.DurationFlag{
+			Name:  "rolling-timeout",
+			Value: 2 * time.Minute,
+			Usage: "Give up when a restarted container isn't healthy after this long.",
+		},
+		&cli.StringFlag{
+			Name:  "remove-volumes-for",
+			Usage: "Stop this service, remove its volumes and start it again.",
+		},
 	},
 	Before: operatorbase.BeforeConfig([]string{"docker", "compose"}),
-	Action: func(ctx context.Context, cmd *cli.Command) error {
+	Action: operatorbase.FanOut(func(ctx context.Context, cmd *cli.Command) error {
+		if service := cmd.String("remove-volumes-for"); service != "" {
+			if cmd.Bool("dry-run") || cmd.Bool("rolling") || cmd.Args().Len() > 0 {
+				return errors.New("--remove-volumes-for can't be combined with --dry-run, --rolling or services")
+			}
+
+			return operatorbase.ResetServiceVolumes(ctx, service)
+		}
+
+		if cmd.Bool("rolling") {
+			if cmd.Bool("dry-run") {
+				return errors.New("--rolling can't be combined with --dry-run")
+			}
+
+			return operatorbase.RollingRestart(ctx, cmd.Args().Slice(), cmd.Duration("rolling-timeout"))
+		}
+
 		if cmd.Bool("dry-run") {
-			return operatorbase.RunCompose(ctx, []string{"restart", "--dry-run"})
+			return operatorbase.RunCompose(ctx, append([]string{"restart", "--dry-run"}, cmd.Args().Slice()...))
 		}
 
-		return operatorbase.RunCompose(ctx, []string{"restart"})
-	},
+		return operatorbase.RunCompose(ctx, append([]string{"restart"}, cmd.Args().Slice()...))
+	}),
 }
 
 var execCmd = &cli.Command{
@@ -68,7 +702,7 @@ var execCmd = &cli.Command{
 	Usage:     "run docker compose exec",
 	ArgsUsage: "[service] [command]",
 	Before:    operatorbase.BeforeConfig([]string{"docker", "compose"}),
-	Action: func(ctx context.Context, cmd *cli.Command) error {
+	Action: operatorbase.Single(func(ctx context.Context, cmd *cli.Command) error {
 		args := []string{"exec"}
 
 		if cmd.Args().Len() > 0 {
@@ -76,11 +710,12 @@ var execCmd = &cli.Command{
 		}
 
 		return operatorbase.RunCompose(ctx, args)
-	},
+	}),
 }
 
 var logsCmd = &cli.Command{
 	Name:      "logs",
+	Aliases:   []string{"log"},
 	Usage:     "run docker compose logs",
 	ArgsUsage: "[service]",
 	Flags: []cli.Flag{
@@ -89,51 +724,868 @@ var logsCmd = &cli.Command{
 			Aliases: []string{"f"},
 			Usage:   "Follow the logs.",
 		},
+		&cli.IntFlag{
+			Name:  "max-bytes",
+			Usage: "Stop after this many bytes of log output.",
+		},
+		&cli.BoolFlag{
+			Name:  "since-deploy",
+			Usage: "Only show the logs since the last deploy.",
+		},
+		&cli.StringFlag{
+			Name:  "grep",
+			Usage: "Only print the lines matching this regular expression.",
+		},
+		&cli.BoolFlag{
+			Name:  "grep-invert",
+			Usage: "Only print the lines not matching --grep.",
+		},
 	},
 	Before: operatorbase.BeforeConfig([]string{"docker", "compose"}),
-	Action: func(ctx context.Context, cmd *cli.Command) error {
+	Action: operatorbase.FanOutParallel(func(ctx context.Context, cmd *cli.Command) error {
 		args := []string{"logs"}
 
 		if cmd.Bool("follow") {
 			args = append(args, "--follow")
 		}
 
+		if cmd.Bool("since-deploy") {
+			deployedAt, err := operatorbase.LastDeployTime(operatorbase.ComposeFilePath(ctx))
+			if err != nil {
+				return err
+			}
+
+			if deployedAt.IsZero() {
+				logger := ctx.Value(operatorbase.LoggerKey{}).(log.Logger)
+				logger.Warn("No deploy recorded, showing all logs")
+			} else {
+				args = append(args, "--since", deployedAt.Format(time.RFC3339))
+			}
+		}
+
 		if cmd.Args().Len() > 0 {
 			args = append(args, cmd.Args().Slice()...)
 		}
 
+		maxBytes := cmd.Int("max-bytes")
+		if maxBytes < 0 {
+			return errors.New("--max-bytes must not be negative")
+		}
+
+		if cmd.Bool("grep-invert") && cmd.String("grep") == "" {
+			return errors.New("--grep-invert requires --grep")
+		}
+
+		if maxBytes == 0 {
+			return runLogs(ctx, cmd, args)
+		}
+
+		// Stop docker compose once the limit has been reached.
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		limitWriter := operatorbase.NewLimitWriter(operatorbase.Stdout(ctx), maxBytes, cancel)
+		ctx = context.WithValue(ctx, operatorbase.StdoutKey{}, limitWriter)
+
+		err := runLogs(ctx, cmd, args)
+		if limitWriter.Reached() {
+			return nil
+		}
+
+		return err
+	}),
+}
+
+// runLogs runs docker compose logs, filtering its output through --grep when set.
+func runLogs(ctx context.Context, cmd *cli.Command, args []string) error {
+	pattern := cmd.String("grep")
+	if pattern == "" {
 		return operatorbase.RunCompose(ctx, args)
-	},
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid --grep pattern: %w", err)
+	}
+
+	grepWriter := operatorbase.NewGrepWriter(operatorbase.Stdout(ctx), re, cmd.Bool("grep-invert"))
+
+	err = operatorbase.RunCompose(context.WithValue(ctx, operatorbase.StdoutKey{}, grepWriter), args)
+
+	if fErr := grepWriter.Flush(); fErr != nil && err == nil {
+		err = fErr
+	}
+
+	return err
 }
 
 var composeCmd = &cli.Command{
 	Name:   "compose",
 	Usage:  "Runs docker compose commands.",
 	Before: operatorbase.BeforeConfig([]string{"docker", "compose"}),
-	Action: func(ctx context.Context, cmd *cli.Command) error {
+	Action: operatorbase.FanOut(func(ctx context.Context, cmd *cli.Command) error {
 		// Capture arguments after "--"
 		if idx := slices.Index(cmd.Args().Slice(), "--"); idx != -1 {
 			args := cmd.Args().Slice()[idx+1:]
 			return operatorbase.RunCompose(ctx, args)
 		}
 		return operatorbase.RunCompose(ctx, []string{})
-	},
+	}),
 }
 
 var statusCmd = &cli.Command{
-	Name:   "status",
-	Usage:  "run docker compose ps -a",
-	Before: operatorbase.BeforeConfig([]string{"docker", "compose"}),
-	Action: func(ctx context.Context, cmd *cli.Command) error {
-		return operatorbase.RunCompose(ctx, []string{"ps", "-a"})
+	Name:  "status",
+	Usage: "run docker compose ps -a",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "check",
+			Usage: "Fail if any service is not running, or not healthy when it has a healthcheck.",
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "Print every container with this Go template, e.g. '{{.Name}}: {{.State}}'.",
+		},
 	},
+	Before: operatorbase.BeforeConfig([]string{"docker", "compose"}),
+	Action: operatorbase.FanOut(func(ctx context.Context, cmd *cli.Command) error {
+		if format := cmd.String("format"); format != "" {
+			if cmd.Bool("check") {
+				return errors.New("--format can't be combined with --check")
+			}
+
+			tmpl, err := template.New("status").Parse(format)
+			if err != nil {
+				return fmt.Errorf("invalid --format template: %w", err)
+			}
+
+			containers, err := operatorbase.ListContainers(ctx)
+			if err != nil {
+				return err
+			}
+
+			w := operatorbase.Stdout(ctx)
+
+			for _, c := range containers {
+				if err := tmpl.Execute(w, c); err != nil {
+					return fmt.Errorf("while executing the --format template: %w", err)
+				}
+
+				fmt.Fprintln(w)
+			}
+
+			return nil
+		}
+
+		if !cmd.Bool("check") {
+			return operatorbase.RunCompose(ctx, []string{"ps", "-a"})
+		}
+
+		unhealthy, err := operatorbase.CheckServices(ctx)
+		if err != nil {
+			return err
+		}
+
+		if len(unhealthy) == 0 {
+			return nil
+		}
+
+		for _, svc := range unhealthy {
+			fmt.Fprintf(operatorbase.Stdout(ctx), "%s: %s\n", svc.Service, svc.Reason)
+		}
+
+		return fmt.Errorf("%d services are not running or not healthy", len(unhealthy))
+	}),
 }
 
 var showCmd = &cli.Command{
-	Name:   "show",
-	Usage:  "run docker compose config",
+	Name:  "show",
+	Usage: "run docker compose config",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "hash",
+			Usage: "Print the config hash of every service.",
+		},
+	},
 	Before: operatorbase.BeforeConfig([]string{"docker", "compose"}),
-	Action: func(ctx context.Context, cmd *cli.Command) error {
+	Action: operatorbase.FanOut(func(ctx context.Context, cmd *cli.Command) error {
+		if cmd.Bool("hash") {
+			return operatorbase.RunCompose(ctx, []string{"config", "--hash", "*"})
+		}
+
 		return operatorbase.RunCompose(ctx, []string{"config"})
+	}),
+}
+
+var inspectCmd = &cli.Command{
+	Name:      "inspect",
+	Usage:     "print the rendered definition of a service",
+	ArgsUsage: "[service]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "format",
+			Value: "yaml",
+			Usage: "Print the definition as yaml or json.",
+		},
+	},
+	Before: operatorbase.BeforeConfig([]string{"docker", "compose"}),
+	Action: operatorbase.FanOut(func(ctx context.Context, cmd *cli.Command) error {
+		if cmd.Args().Len() != 1 {
+			return errors.New("inspect requires exactly one service")
+		}
+
+		mime := codecs.MimeYAML
+
+		switch cmd.String("format") {
+		case "yaml":
+		case "json":
+			mime = codecs.MimeJSON
+		default:
+			return fmt.Errorf("invalid format '%s', expected yaml or json", cmd.String("format"))
+		}
+
+		svc, err := operatorbase.ServiceConfig(operatorbase.ComposeFilePath(ctx), cmd.Args().First())
+		if err != nil {
+			return err
+		}
+
+		return printEncoded(operatorbase.Stdout(ctx), mime, svc)
+	}),
+}
+
+var watchCmd = &cli.Command{
+	Name:  "watch",
+	Usage: "run docker compose watch",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "no-up",
+			Usage: "Do not build and start the services before watching.",
+		},
+	},
+	Before: operatorbase.BeforeConfig([]string{"docker", "compose"}),
+	Action: operatorbase.FanOutParallel(func(ctx context.Context, cmd *cli.Command) error {
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		args := []string{"watch"}
+
+		if cmd.Bool("no-up") {
+			args = append(args, "--no-up")
+		}
+
+		err := operatorbase.RunCompose(ctx, args)
+		if ctx.Err() != nil {
+			// Interrupted by the user.
+			return nil
+		}
+
+		return err
+	}),
+}
+
+var diffCmd = &cli.Command{
+	Name:  "diff",
+	Usage: "show the changes of the rendered config since the last start",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "format",
+			Value: "text",
+			Usage: "Set the output format (text, json).",
+		},
+	},
+	Before: operatorbase.BeforeConfig([]string{"docker", "compose"}),
+	Action: operatorbase.FanOut(func(ctx context.Context, cmd *cli.Command) error {
+		format := cmd.String("format")
+		if format != "text" && format != "json" {
+			return fmt.Errorf("invalid format '%s', expected text or json", format)
+		}
+
+		composeFilePath := operatorbase.ComposeFilePath(ctx)
+
+		deployed, err := operatorbase.LoadComposeFile(operatorbase.DeployedFilePath(composeFilePath))
+		if err != nil {
+			return err
+		}
+
+		rendered, err := operatorbase.LoadComposeFile(composeFilePath)
+		if err != nil {
+			return err
+		}
+
+		changeset := operatorbase.Diff(deployed, rendered)
+
+		if format == "json" {
+			return printJSON(operatorbase.Stdout(ctx), changeset)
+		}
+
+		printChangeset(operatorbase.Stdout(ctx), changeset)
+
+		return nil
+	}),
+}
+
+var exportCmd = &cli.Command{
+	Name:  "export",
+	Usage: "export the rendered stack with its local files as a tarball",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "output",
+			Aliases:  []string{"o"},
+			Usage:    "Write the tarball to this file.",
+			Required: true,
+		},
+	},
+	Before: operatorbase.BeforeConfig([]string{"docker", "compose"}),
+	Action: operatorbase.Single(func(ctx context.Context, cmd *cli.Command) error {
+		fp, err := os.OpenFile(cmd.String("output"), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+		if err != nil {
+			return fmt.Errorf("while creating '%s': %w", cmd.String("output"), err)
+		}
+
+		if err := operatorbase.Export(ctx, fp); err != nil {
+			_ = fp.Close()
+			return err
+		}
+
+		return fp.Close()
+	}),
+}
+
+// printJSON writes v as JSON to w.
+func printJSON(w io.Writer, v any) error {
+	return printEncoded(w, codecs.MimeJSON, v)
+}
+
+// printEncoded writes v encoded with the codec of mime to w.
+func printEncoded(w io.Writer, mime string, v any) error {
+	codec, err := codecs.GetMime(mime)
+	if err != nil {
+		return fmt.Errorf("while getting codec: %w", err)
+	}
+
+	b, err := codec.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("while marshalling: %w", err)
+	}
+
+	_, err = fmt.Fprintln(w, string(b))
+
+	return err
+}
+
+// printPlan writes a human readable deploy plan to w.
+func printPlan(w io.Writer, plan *operatorbase.Plan) {
+	if plan.Empty() {
+		fmt.Fprintln(w, "No changes.")
+		return
+	}
+
+	for _, name := range plan.Create {
+		fmt.Fprintf(w, "+ %s (create)\n", name)
+	}
+
+	for _, name := range plan.Recreate {
+		fmt.Fprintf(w, "~ %s (recreate)\n", name)
+	}
+
+	for _, name := range plan.Orphaned {
+		fmt.Fprintf(w, "- %s (orphaned, removed with --remove-orphans)\n", name)
+	}
+}
+
+// printChangeset writes a human readable changeset to w.
+func printChangeset(w io.Writer, changeset *operatorbase.Changeset) {
+	if changeset.Empty() {
+		fmt.Fprintln(w, "No changes.")
+		return
+	}
+
+	for _, name := range changeset.Services.Added {
+		fmt.Fprintf(w, "+ %s\n", name)
+	}
+
+	for _, name := range changeset.Services.Removed {
+		fmt.Fprintf(w, "- %s\n", name)
+	}
+
+	names := make([]string, 0, len(changeset.Services.Changed))
+	for name := range changeset.Services.Changed {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	for _, name := range names {
+		changes := changeset.Services.Changed[name]
+		fmt.Fprintf(w, "~ %s\n", name)
+
+		for _, key := range changes.Added {
+			fmt.Fprintf(w, "    + %s\n", key)
+		}
+
+		for _, key := range changes.Removed {
+			fmt.Fprintf(w, "    - %s\n", key)
+		}
+
+		for _, key := range changes.Modified {
+			fmt.Fprintf(w, "    ~ %s\n", key)
+		}
+	}
+}
+
+var ensureCmd = &cli.Command{
+	Name:   "ensure",
+	Usage:  "run docker compose up -d only when the running stack diverges from the config",
+	Before: operatorbase.BeforeConfig([]string{"docker", "compose"}),
+	Action: operatorbase.FanOut(func(ctx context.Context, cmd *cli.Command) error {
+		logger := ctx.Value(operatorbase.LoggerKey{}).(log.Logger)
+		composeFilePath := operatorbase.ComposeFilePath(ctx)
+
+		inSync, err := operatorbase.InSync(ctx)
+		if err != nil {
+			return err
+		}
+
+		if inSync {
+			logger.Info("Stack is up to date, nothing to do")
+			return nil
+		}
+
+		logger.Info("Stack diverges from the config, applying")
+
+		if err := operatorbase.RunCompose(ctx, []string{"up", "-d"}); err != nil {
+			return err
+		}
+
+		return operatorbase.RecordDeploy(composeFilePath)
+	}),
+}
+
+var reloadCmd = &cli.Command{
+	Name:   "reload",
+	Usage:  "re-render the config and apply it with docker compose up -d --remove-orphans",
+	Before: operatorbase.BeforeConfig([]string{"docker", "compose"}),
+	Action: operatorbase.FanOut(reloadAction),
+}
+
+// reloadAction applies the rendered compose file unless it has already been deployed.
+func reloadAction(ctx context.Context, _ *cli.Command) error {
+	logger := ctx.Value(operatorbase.LoggerKey{}).(log.Logger)
+	composeFilePath := operatorbase.ComposeFilePath(ctx)
+
+	hash, err := operatorbase.FileHash(composeFilePath)
+	if err != nil {
+		return err
+	}
+
+	lastHash, err := operatorbase.LastDeployHash(composeFilePath)
+	if err != nil {
+		return err
+	}
+
+	if hash == lastHash {
+		logger.Info("Config unchanged since the last deploy, nothing to reload")
+		return nil
+	}
+
+	if err := operatorbase.RunCompose(ctx, []string{"up", "-d", "--remove-orphans"}); err != nil {
+		return err
+	}
+
+	return operatorbase.RecordDeploy(composeFilePath)
+}
+
+var daemonCmd = &cli.Command{
+	Name:  "daemon",
+	Usage: "apply the config and re-apply it whenever the config file changes",
+	Flags: []cli.Flag{
+		&cli.DurationFlag{
+			Name:  "interval",
+			Value: 2 * time.Second,
+			Usage: "Check the config file for changes this often.",
+		},
+		&cli.DurationFlag{
+			Name:  "debounce",
+			Value: time.Second,
+			Usage: "Wait until the config file didn't change for this long before applying it.",
+		},
+		&cli.StringFlag{
+			Name:  "health-addr",
+			Usage: "Serve the liveness and the last reconcile result as JSON on this address (e.g. :8080).",
+		},
+	},
+	Before: operatorbase.BeforeConfig([]string{"docker", "compose"}),
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		logger := ctx.Value(operatorbase.LoggerKey{}).(log.Logger)
+
+		if cmd.String("config") == "" || cmd.String("config") == "-" || operatorbase.IsConfigURL(cmd.String("config")) {
+			return errors.New("the daemon can only watch a config file")
+		}
+
+		if cmd.Duration("interval") <= 0 {
+			return errors.New("--interval must be positive")
+		}
+
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		operatorbase.StartReaper(ctx)
+
+		health := operatorbase.NewHealthState()
+
+		if addr := cmd.String("health-addr"); addr != "" {
+			listener, err := operatorbase.ListenHealth(addr)
+			if err != nil {
+				return err
+			}
+
+			logger.Info("Serving the health endpoint", "addr", listener.Addr().String())
+
+			go func() {
+				if err := operatorbase.ServeHealth(ctx, listener, health); err != nil {
+					logger.Error("Error while serving the health endpoint", "error", err)
+				}
+			}()
+		}
+
+		reload := operatorbase.FanOut(reloadAction)
+		before := operatorbase.BeforeConfig([]string{"docker", "compose"})
+
+		logger.Info("Reconciling", "config", cmd.String("config"))
+
+		err := reload(ctx, cmd)
+		if err != nil {
+			logger.Error("Error while reconciling", "error", err)
+		}
+
+		health.Record(err)
+
+		err = operatorbase.WatchFile(ctx, cmd.String("config"), cmd.Duration("interval"), cmd.Duration("debounce"), func() {
+			logger.Info("Config changed, reconciling", "config", cmd.String("config"))
+
+			// With --no-cache-write every reconcile renders into a new temporary directory.
+			defer operatorbase.Cleanup()
+
+			reloadCtx, err := before(ctx, cmd)
+			if err == nil {
+				err = reload(reloadCtx, cmd)
+			}
+
+			health.Record(err)
+
+			if err != nil {
+				logger.Error("Error while reconciling", "error", err)
+				return
+			}
+
+			logger.Info("Reconciled")
+		})
+
+		logger.Info("Stopping the daemon")
+
+		return err
+	},
+}
+
+var pruneCmd = &cli.Command{
+	Name:  "prune",
+	Usage: "remove stopped containers, dangling images and unused anonymous volumes of the project",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "Only list what would be removed.",
+		},
+		&cli.BoolFlag{
+			Name:  "volumes",
+			Usage: "Also remove unused named volumes.",
+		},
+	},
+	Before: operatorbase.BeforeConfig([]string{"docker", "compose"}),
+	Action: operatorbase.FanOut(func(ctx context.Context, cmd *cli.Command) error {
+		filter := "label=com.docker.compose.project=" + operatorbase.CurrentProject(ctx).Name
+
+		if cmd.Bool("dry-run") {
+			queries := [][]string{
+				{"container", "ls", "-a", "--filter", "status=exited", "--filter", "status=created", "--filter", filter},
+				{"image", "ls", "--filter", "dangling=true", "--filter", filter},
+			}
+
+			if cmd.Bool("volumes") {
+				queries = append(queries, []string{"volume", "ls", "--filter", "dangling=true", "--filter", filter})
+			}
+
+			for _, args := range queries {
+				if err := operatorbase.RunDocker(ctx, args); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}
+
+		volumeArgs := []string{"volume", "prune", "-f", "--filter", filter}
+		if cmd.Bool("volumes") {
+			volumeArgs = append(volumeArgs, "--all")
+		}
+
+		for _, args := range [][]string{
+			{"container", "prune", "-f", "--filter", filter},
+			{"image", "prune", "-f", "--filter", filter},
+			volumeArgs,
+		} {
+			if err := operatorbase.RunDocker(ctx, args); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}),
+}
+
+var lsCmd = &cli.Command{
+	Name:  "ls",
+	Usage: "list the projects managed on this host",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "format",
+			Value: "text",
+			Usage: "Set the output format (text, json).",
+		},
+	},
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		projects, err := operatorbase.ListManagedProjects()
+		if err != nil {
+			return err
+		}
+
+		switch cmd.String("format") {
+		case "json":
+			return printJSON(os.Stdout, projects)
+		case "text":
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tLAST DEPLOY")
+
+			for _, project := range projects {
+				fmt.Fprintf(w, "%s\t%s\n", project.Name, project.LastDeploy.Format(time.RFC3339))
+			}
+
+			return w.Flush()
+		default:
+			return fmt.Errorf("invalid format '%s', expected text or json", cmd.String("format"))
+		}
+	},
+}
+
+var topCmd = &cli.Command{
+	Name:      "top",
+	Usage:     "run docker compose top",
+	ArgsUsage: "[service...]",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "Print the processes of every service as JSON.",
+		},
+	},
+	Before: operatorbase.BeforeConfig([]string{"docker", "compose"}),
+	Action: operatorbase.FanOut(func(ctx context.Context, cmd *cli.Command) error {
+		if !cmd.Bool("json") {
+			return operatorbase.RunCompose(ctx, append([]string{"top"}, cmd.Args().Slice()...))
+		}
+
+		processes, err := operatorbase.TopByService(ctx)
+		if err != nil {
+			return err
+		}
+
+		if cmd.Args().Len() > 0 {
+			for service := range processes {
+				if !slices.Contains(cmd.Args().Slice(), service) {
+					delete(processes, service)
+				}
+			}
+		}
+
+		return printJSON(operatorbase.Stdout(ctx), processes)
+	}),
+}
+
+var eventsCmd = &cli.Command{
+	Name:  "events",
+	Usage: "stream the container events of the project",
+	Flags: []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:  "filter",
+			Usage: "Only show matching events (type=start,die, service=web, container=name), may be repeated.",
+		},
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "Print one JSON object per event.",
+		},
+	},
+	Before: operatorbase.BeforeConfig([]string{"docker", "compose"}),
+	Action: operatorbase.FanOutParallel(func(ctx context.Context, cmd *cli.Command) error {
+		filterArgs, err := operatorbase.EventFilterArgs(cmd.StringSlice("filter"))
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		args := []string{"events", "--filter", "type=container", "--filter", "label=com.docker.compose.project=" + operatorbase.CurrentProject(ctx).Name}
+		args = append(args, filterArgs...)
+
+		if cmd.Bool("json") {
+			args = append(args, "--format", "{{json .}}")
+		}
+
+		err = operatorbase.RunDocker(ctx, args)
+		if ctx.Err() != nil {
+			// Interrupted by the user.
+			return nil
+		}
+
+		return err
+	}),
+}
+
+var rollbackCmd = &cli.Command{
+	Name:  "rollback",
+	Usage: "redeploy a previous version of the compose file from the history",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "to",
+			Usage: "Roll back to the version with this timestamp instead of the one before the last deploy.",
+		},
+		&cli.BoolFlag{
+			Name:  "list",
+			Usage: "List the versions in the history.",
+		},
+	},
+	Before: operatorbase.BeforeConfig([]string{"docker", "compose"}),
+	Action: operatorbase.FanOut(func(ctx context.Context, cmd *cli.Command) error {
+		logger := ctx.Value(operatorbase.LoggerKey{}).(log.Logger)
+		composeFilePath := operatorbase.ComposeFilePath(ctx)
+
+		versions, err := operatorbase.ListHistory(composeFilePath)
+		if err != nil {
+			return err
+		}
+
+		if cmd.Bool("list") {
+			for _, version := range versions {
+				marker := ""
+				if version.Current {
+					marker = " (deployed)"
+				}
+
+				fmt.Fprintln(operatorbase.Stdout(ctx), version.Timestamp+marker)
+			}
+
+			return nil
+		}
+
+		var target operatorbase.HistoryVersion
+
+		if to := cmd.String("to"); to != "" {
+			idx := slices.IndexFunc(versions, func(v operatorbase.HistoryVersion) bool { return v.Timestamp == to })
+			if idx < 0 {
+				return fmt.Errorf("version '%s' not found in the history", to)
+			}
+
+			target = versions[idx]
+		} else {
+			previous, ok, err := operatorbase.PreviousHistory(composeFilePath)
+			if err != nil {
+				return err
+			}
+
+			if !ok {
+				return errors.New("no previous version in the history")
+			}
+
+			target = previous
+		}
+
+		logger.Info("Rolling back", "version", target.Timestamp)
+
+		return rollbackTo(ctx, target)
+	}),
+}
+
+// rollbackTo makes target the current compose file and deploys it.
+func rollbackTo(ctx context.Context, target operatorbase.HistoryVersion) error {
+	composeFilePath := operatorbase.ComposeFilePath(ctx)
+
+	if err := operatorbase.RestoreHistory(composeFilePath, target); err != nil {
+		return err
+	}
+
+	if err := operatorbase.RunCompose(ctx, []string{"up", "-d", "--remove-orphans"}); err != nil {
+		return err
+	}
+
+	return operatorbase.RecordRollback(composeFilePath, target)
+}
+
+var verifyCmd = &cli.Command{
+	Name:   "verify",
+	Usage:  "check that the image of every service exists in its registry",
+	Flags:  registryFlags(),
+	Before: operatorbase.BeforeConfig([]string{"docker", "compose"}),
+	Action: operatorbase.FanOut(func(ctx context.Context, cmd *cli.Command) error {
+		if err := operatorbase.RegistryLogin(ctx, cmd); err != nil {
+			return err
+		}
+
+		return verifyImages(ctx)
+	}),
+}
+
+// verifyImages reports the service images which can't be resolved in their registry.
+func verifyImages(ctx context.Context) error {
+	unresolved, err := operatorbase.VerifyImages(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(unresolved) == 0 {
+		return nil
+	}
+
+	for _, image := range unresolved {
+		fmt.Fprintf(operatorbase.Stdout(ctx), "%s: image %s not found\n", image.Service, image.Image)
+	}
+
+	return fmt.Errorf("%d images can't be resolved", len(unresolved))
+}
+
+var validateCmd = &cli.Command{
+	Name:  "validate",
+	Usage: "check the config without rendering a compose file or running docker",
+	Action: func(ctx context.Context, cmd *cli.Command) error {
+		logger, err := log.New(log.WithLevel(cmd.String("log-level")))
+		if err != nil {
+			return err
+		}
+
+		errs := operatorbase.ValidateConfig(ctx, logger, cmd)
+		if len(errs) == 0 {
+			fmt.Fprintln(os.Stdout, "The config is valid.")
+			return nil
+		}
+
+		for _, err := range errs {
+			fmt.Fprintln(os.Stdout, err)
+		}
+
+		return fmt.Errorf("the config has %d errors", len(errs))
+	},
+}
+
+var schemaCmd = &cli.Command{
+	Name:  "schema",
+	Usage: "print the JSON schema of the config",
+	Action: func(_ context.Context, _ *cli.Command) error {
+		return printJSON(os.Stdout, operatorbase.ConfigSchema())
 	},
 }
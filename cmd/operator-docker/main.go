@@ -2,12 +2,17 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"time"
 
 	"github.com/earthboundkid/versioninfo/v2"
 	"github.com/urfave/cli/v3"
 
+	"github.com/octocompose/operator-docker/pkg/operatorbase"
+
 	_ "github.com/go-orb/plugins/codecs/json"
+	_ "github.com/go-orb/plugins/codecs/toml"
 	_ "github.com/go-orb/plugins/codecs/yaml"
 	_ "github.com/go-orb/plugins/log/slog"
 )
@@ -22,18 +27,190 @@ func main() {
 		Name:    "octoctl",
 		Version: Version,
 		Usage:   "Docker Compose Operator",
+		// Runs before the Before of the subcommands, which read the config and resolve the project directory.
+		Before: func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+			dir := cmd.String("cwd")
+			if dir == "" {
+				return ctx, nil
+			}
+
+			if err := os.Chdir(dir); err != nil {
+				return ctx, fmt.Errorf("while changing to directory '%s': %w", dir, err)
+			}
+
+			return ctx, nil
+		},
 		Flags: []cli.Flag{
 			&cli.StringFlag{
-				Name:     "config",
-				Aliases:  []string{"c"},
-				Usage:    "Set the config file",
-				Required: true,
+				Name:  "cwd",
+				Usage: "Change to this directory first, relative --config, --config-dir and --project-directory paths are resolved against it",
+			},
+			&cli.StringFlag{
+				Name:    "config",
+				Aliases: []string{"c"},
+				Usage:   "Set the config file, use - to read it from stdin or an http(s) URL to fetch it",
+			},
+			&cli.StringFlag{
+				Name:    "config-token",
+				Usage:   "Send this bearer token when fetching the config from a URL",
+				Sources: cli.EnvVars("OCTOCOMPOSE_CONFIG_TOKEN"),
+			},
+			&cli.DurationFlag{
+				Name:  "config-timeout",
+				Value: 30 * time.Second,
+				Usage: "Give up fetching the config from a URL after this long",
+			},
+			&cli.StringFlag{
+				Name:  "config-dir",
+				Usage: "Merge the config from all .json, .yaml, .yml and .toml files of this directory in lexical order",
+			},
+			&cli.IntFlag{
+				Name:  "max-config-size",
+				Value: 16 << 20,
+				Usage: "Refuse configs larger than this many bytes",
+			},
+			&cli.StringFlag{
+				Name:  "config-format",
+				Usage: "Set the config format (json, yaml, toml), defaults to the file extension",
 			},
 			&cli.StringFlag{
 				Name:    "log-level",
 				Aliases: []string{"l"},
 				Value:   "info",
-				Usage:   "Set the log level (debug, info, warn, error)",
+				Usage:   "Set the log level (trace, debug, info, warn, error), trace dumps the config and environment",
+			},
+			&cli.StringFlag{
+				Name:  "file-mode",
+				Value: "0600",
+				Usage: "Set the octal file mode of the rendered compose file and the files written next to it",
+			},
+			&cli.StringFlag{
+				Name:  "log-driver",
+				Usage: "Set the logging driver of every service that doesn't define one",
+			},
+			&cli.StringSliceFlag{
+				Name:  "log-opt",
+				Usage: "Set a logging driver option (key=value), may be repeated",
+			},
+			&cli.StringFlag{
+				Name:  "command-precedence",
+				Value: "repo",
+				Usage: "Set whether the repo command/entrypoint overrides the service's (repo) or only fills it in (service)",
+			},
+			&cli.StringFlag{
+				Name:  "compose-command",
+				Usage: "Run this compose command (e.g. \"podman compose\"), overrides octoctl.commands and octoctl.composeCommand",
+			},
+			&cli.BoolFlag{
+				Name:  "compatibility",
+				Usage: "Run docker compose in backward compatibility mode",
+			},
+			&cli.BoolFlag{
+				Name:  "no-env-file-discovery",
+				Usage: "Do not pass the .env file next to the config file to docker compose",
+			},
+			&cli.BoolFlag{
+				Name:    "read-only",
+				Usage:   "Only allow commands which inspect the stack",
+				Sources: cli.EnvVars("OCTOCOMPOSE_READONLY"),
+			},
+			&cli.BoolFlag{
+				Name: "force-write",
+				Usage: "Run a mutating command despite --read-only, for emergencies only; " +
+					"also requires OCTOCOMPOSE_FORCE_WRITE set to the command name",
+			},
+			&cli.BoolFlag{
+				Name:  "env-substitution-strict",
+				Usage: "Fail on referenced variables which are undefined and have no default",
+			},
+			&cli.StringSliceFlag{
+				Name:  "add-host",
+				Usage: "Add a host:ip mapping to every service, may be repeated",
+			},
+			&cli.StringFlag{
+				Name:  "volume-prefix",
+				Usage: "Prefix the names of all non-external volumes",
+			},
+			&cli.StringSliceFlag{
+				Name:  "profile",
+				Usage: "Enable a compose profile in addition to octoctl.profiles of the config, may be repeated",
+			},
+			&cli.StringFlag{
+				Name:  "project-directory",
+				Usage: "Set the compose project directory, overrides octoctl.workingDir of the config",
+			},
+			&cli.BoolFlag{
+				Name:  "sudo",
+				Usage: "Run docker through a privilege escalation command, also enabled by octoctl.sudo",
+			},
+			&cli.StringFlag{
+				Name:  "sudo-command",
+				Usage: "Use this privilege escalation command instead of octoctl.sudoCommand or sudo, commands other than sudo and run0 get the environment as visible arguments",
+			},
+			&cli.BoolFlag{
+				Name:  "redact-config-in-logs",
+				Value: true,
+				Usage: "Mask the values of sensitive keys when the config and environment are logged at trace level",
+			},
+			&cli.StringSliceFlag{
+				Name:  "redact-keys",
+				Value: []string{"*password*", "*secret*", "*token*", "*_key"},
+				Usage: "Mask the values of keys matching these glob patterns (case insensitive) in trace logs",
+			},
+			&cli.StringFlag{
+				Name:  "status-file",
+				Usage: "Write the command, project, exit code and time as JSON to this file after every command",
+			},
+			&cli.IntFlag{
+				Name:  "parallel-pull-limit",
+				Usage: "Limit the parallel operations (pulls, builds, ...) of docker compose with COMPOSE_PARALLEL_LIMIT",
+			},
+			&cli.StringFlag{
+				Name:  "network-driver",
+				Usage: "Set the driver of the default network unless the config defines it, overrides octoctl.networkDriver",
+			},
+			&cli.IntFlag{
+				Name:  "network-mtu",
+				Usage: "Set the MTU of the default network unless the config defines it, overrides octoctl.networkMTU",
+			},
+			&cli.StringFlag{
+				Name:  "stop-grace-period",
+				Usage: "Set the stop_grace_period of services without one or octocompose.stopGracePeriod (e.g. 30s)",
+			},
+			&cli.StringFlag{
+				Name:  "metrics-file",
+				Usage: "Write the duration, exit code and service count of every command to this file in the Prometheus textfile format",
+			},
+			&cli.BoolFlag{
+				Name:  "translate-replicas",
+				Usage: "Translate deploy.replicas of every service to scale, which docker compose honors without swarm or --compatibility",
+			},
+			&cli.StringFlag{
+				Name:  "services-from-file",
+				Usage: "Only deploy the services listed in this file (one per line) and their dependencies",
+			},
+			&cli.StringFlag{
+				Name:  "project",
+				Usage: "Only run against this project of a multi-project config",
+			},
+			&cli.StringFlag{
+				Name:    "compose-file",
+				Usage:   "Write the rendered compose file to this path instead of the cache directory",
+				Sources: cli.EnvVars("OCTOCOMPOSE_COMPOSE_FILE"),
+			},
+			&cli.BoolFlag{
+				Name:  "no-cache-write",
+				Usage: "Render the compose file to a temporary directory which is removed afterwards",
+			},
+			&cli.StringFlag{
+				Name:  "error-format",
+				Value: "text",
+				Usage: "Set the error format (text, json), json prints a structured error to stderr",
+			},
+			&cli.BoolFlag{
+				Name:    "line-buffered",
+				Aliases: []string{"no-ansi-clear"},
+				Usage:   "Write the output of commands in whole lines, keeps concurrent output readable",
 			},
 		},
 		Commands: []*cli.Command{
@@ -45,10 +222,64 @@ func main() {
 			composeCmd,
 			statusCmd,
 			showCmd,
+			watchCmd,
+			diffCmd,
+			exportCmd,
+			ensureCmd,
+			reloadCmd,
+			pruneCmd,
+			lsCmd,
+			topCmd,
+			buildCmd,
+			daemonCmd,
+			eventsCmd,
+			rollbackCmd,
+			verifyCmd,
+			validateCmd,
+			schemaCmd,
+			inspectCmd,
 		},
 	}
 
-	if err := cmd.Run(context.Background(), os.Args); err != nil {
-		os.Exit(1)
+	started := time.Now()
+	err := cmd.Run(context.Background(), os.Args)
+
+	operatorbase.Cleanup()
+
+	if path := cmd.String("metrics-file"); path != "" {
+		run := operatorbase.RunMetrics{
+			Command:  cmd.Args().First(),
+			ExitCode: operatorbase.ExitCode(err),
+			Duration: time.Since(started),
+			Finished: time.Now(),
+		}
+
+		if mErr := operatorbase.WriteMetricsFile(path, run); mErr != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", mErr)
+		}
+	}
+
+	if path := cmd.String("status-file"); path != "" {
+		status := operatorbase.Status{
+			Command:   cmd.Args().First(),
+			Projects:  operatorbase.RenderedProjects(),
+			ExitCode:  operatorbase.ExitCode(err),
+			Timestamp: time.Now().UTC(),
+		}
+		if len(status.Projects) == 1 {
+			status.Project = status.Projects[0]
+		}
+
+		if err != nil {
+			status.Error = err.Error()
+		}
+
+		if sErr := operatorbase.WriteStatusFile(path, status); sErr != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", sErr)
+		}
+	}
+
+	if err != nil {
+		os.Exit(operatorbase.HandleError(os.Stderr, err, cmd.String("error-format")))
 	}
 }
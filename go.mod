@@ -6,6 +6,7 @@ require (
 	github.com/earthboundkid/versioninfo/v2 v2.24.1
 	github.com/go-orb/go-orb v0.3.0
 	github.com/go-orb/plugins/codecs/json v0.2.0
+	github.com/go-orb/plugins/codecs/toml v0.1.0
 	github.com/go-orb/plugins/codecs/yaml v0.2.0
 	github.com/go-orb/plugins/log/slog v0.2.0
 	github.com/octocompose/octoctl v0.0.0-20250330151412-fddf32347166
@@ -14,6 +15,7 @@ require (
 
 require (
 	dario.cat/mergo v1.0.1 // indirect
+	github.com/BurntSushi/toml v1.5.0 // indirect
 	github.com/cornelk/hashmap v1.0.8 // indirect
 	github.com/google/uuid v1.6.0 // indirect
 	github.com/hashicorp/errwrap v1.1.0 // indirect
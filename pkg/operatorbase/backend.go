@@ -0,0 +1,107 @@
+package operatorbase
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Compose backends.
+const (
+	BackendDocker        = "docker"
+	BackendPodman        = "podman"
+	BackendPodmanCompose = "podman-compose"
+)
+
+// unsupportedFlags lists the flags a backend doesn't understand,
+// the --pull of podman-compose is a boolean which doesn't take a policy.
+//
+//nolint:gochecknoglobals
+var unsupportedFlags = map[string][]string{
+	BackendPodman:        {"--dry-run", "--wait", "--hash", "--all-resources"},
+	BackendPodmanCompose: {"--dry-run", "--wait", "--hash", "--quiet-pull", "--attach-dependencies", "--all-resources", "--pull"},
+}
+
+// EngineCommand returns the container engine binary of a compose command, the docker or
+// podman CLI, for the commands compose doesn't have (login, volume rm, events, ...).
+func EngineCommand(composeCommand []string) string {
+	if len(composeCommand) > 0 {
+		switch strings.TrimSuffix(filepath.Base(composeCommand[0]), ".exe") {
+		case "docker", "podman":
+			return composeCommand[0]
+		}
+	}
+
+	if DetectBackend(composeCommand) == BackendPodmanCompose {
+		return "podman"
+	}
+
+	return "docker"
+}
+
+// composeValueFlags lists the flags the operator passes with their value as a separate argument.
+//
+//nolint:gochecknoglobals
+var composeValueFlags = map[string]bool{
+	"--pull":           true,
+	"--exit-code-from": true,
+	"--no-attach":      true,
+	"--rmi":            true,
+	"--hash":           true,
+	"--format":         true,
+	"--since":          true,
+	"--filter":         true,
+	"--build-arg":      true,
+}
+
+// commandFlags returns the flags of the compose subcommand args[0] before the first
+// positional, so a flag given to a service command like "exec svc cmd --wait" is never inspected.
+func commandFlags(args []string) []string {
+	flags := []string{}
+
+	for i := 1; i < len(args); i++ {
+		if args[i] == "--" || !strings.HasPrefix(args[i], "-") {
+			break
+		}
+
+		flag, _, inline := strings.Cut(args[i], "=")
+		flags = append(flags, flag)
+
+		if !inline && composeValueFlags[flag] {
+			i++
+		}
+	}
+
+	return flags
+}
+
+// DetectBackend returns the backend of a compose command.
+func DetectBackend(composeCommand []string) string {
+	if len(composeCommand) == 0 {
+		return BackendDocker
+	}
+
+	switch strings.TrimSuffix(filepath.Base(composeCommand[0]), ".exe") {
+	case "podman":
+		return BackendPodman
+	case "podman-compose":
+		return BackendPodmanCompose
+	default:
+		return BackendDocker
+	}
+}
+
+// checkBackendFlags returns an error if the flags of the compose subcommand args contain one the backend doesn't support.
+func checkBackendFlags(composeCommand []string, args []string) error {
+	backend := DetectBackend(composeCommand)
+
+	for _, flag := range commandFlags(args) {
+		for _, unsupported := range unsupportedFlags[backend] {
+			if flag == unsupported {
+				return fmt.Errorf("%s is unsupported on %s", unsupported, backend)
+			}
+		}
+	}
+
+	return nil
+}
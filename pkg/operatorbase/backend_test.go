@@ -0,0 +1,59 @@
+package operatorbase
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCommandFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{name: "no flags", args: []string{"ps"}, want: []string{}},
+		{name: "flags before the services", args: []string{"up", "-d", "--wait", "web"}, want: []string{"-d", "--wait"}},
+		{name: "separate value", args: []string{"up", "--pull", "always", "--wait", "web"}, want: []string{"--pull", "--wait"}},
+		{name: "inline value", args: []string{"up", "--pull=always", "web", "--wait"}, want: []string{"--pull"}},
+		{name: "service command flags", args: []string{"exec", "web", "cmd", "--wait"}, want: []string{}},
+		{name: "double dash", args: []string{"run", "--", "--dry-run"}, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := commandFlags(tt.args); !slices.Equal(got, tt.want) {
+				t.Errorf("commandFlags() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckBackendFlags(t *testing.T) {
+	if err := checkBackendFlags([]string{"podman", "compose"}, []string{"exec", "web", "cmd", "--wait"}); err != nil {
+		t.Errorf("checkBackendFlags() rejected a flag of the service command: %v", err)
+	}
+
+	if err := checkBackendFlags([]string{"podman", "compose"}, []string{"up", "-d", "--wait"}); err == nil {
+		t.Errorf("checkBackendFlags() accepted --wait on podman")
+	}
+}
+
+func TestEngineCommand(t *testing.T) {
+	tests := []struct {
+		composeCommand []string
+		want           string
+	}{
+		{composeCommand: nil, want: "docker"},
+		{composeCommand: []string{"docker", "compose"}, want: "docker"},
+		{composeCommand: []string{"/usr/local/bin/docker", "compose"}, want: "/usr/local/bin/docker"},
+		{composeCommand: []string{"docker-compose"}, want: "docker"},
+		{composeCommand: []string{"podman", "compose"}, want: "podman"},
+		{composeCommand: []string{"/usr/bin/podman-compose"}, want: "podman"},
+	}
+
+	for _, tt := range tests {
+		if got := EngineCommand(tt.composeCommand); got != tt.want {
+			t.Errorf("EngineCommand(%q) = %q, want %q", tt.composeCommand, got, tt.want)
+		}
+	}
+}
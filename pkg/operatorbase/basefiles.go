@@ -0,0 +1,49 @@
+package operatorbase
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v3"
+)
+
+// BaseFilesKey holds the compose files passed before the rendered one.
+type BaseFilesKey struct{}
+
+// BaseFiles returns the absolute paths of octoctl.baseFiles, relative paths are
+// resolved against the directory of the config. It returns an error if a file doesn't exist.
+func BaseFiles(cmd *cli.Command, data map[string]any) ([]string, error) {
+	files, err := OctoctlStringList(data, "baseFiles")
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, 0, len(files))
+
+	for _, file := range files {
+		path, err := resolveConfigPath(cmd, file)
+		if err != nil {
+			return nil, fmt.Errorf("while resolving the base file '%s': %w", file, err)
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("base file '%s': %w", file, err)
+		}
+
+		if info.IsDir() {
+			return nil, fmt.Errorf("base file '%s' is a directory", file)
+		}
+
+		result = append(result, path)
+	}
+
+	return result, nil
+}
+
+// baseFilesFromContext returns the compose files passed before the rendered one.
+func baseFilesFromContext(ctx context.Context) []string {
+	baseFiles, _ := ctx.Value(BaseFilesKey{}).([]string) //nolint:errcheck
+	return baseFiles
+}
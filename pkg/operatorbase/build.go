@@ -0,0 +1,125 @@
+package operatorbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/go-orb/go-orb/codecs"
+	"github.com/urfave/cli/v3"
+)
+
+// BuildArgs validates KEY=VALUE pairs and returns them as docker compose build --build-arg arguments.
+func BuildArgs(pairs []string) ([]string, error) {
+	values, err := ParseKeyValues(pairs)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	args := make([]string, 0, len(keys)*2)
+	for _, key := range keys {
+		args = append(args, "--build-arg", key+"="+values[key])
+	}
+
+	return args, nil
+}
+
+// WithBuildTarget writes an override which sets build.target of every service
+// with a build section and returns a context which passes it to compose.
+func WithBuildTarget(ctx context.Context, target string) (context.Context, error) {
+	composeFilePath := ComposeFilePath(ctx)
+
+	data, err := LoadComposeFile(composeFilePath)
+	if err != nil {
+		return ctx, err
+	}
+
+	services, _ := data["services"].(map[string]any) //nolint:errcheck
+	overrides := map[string]any{}
+
+	for name, svc := range services {
+		svcMap, ok := svc.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		switch build := svcMap["build"].(type) {
+		case string:
+			// The short syntax only holds the context.
+			overrides[name] = map[string]any{"build": map[string]any{"context": build, "target": target}}
+		case map[string]any:
+			overrides[name] = map[string]any{"build": map[string]any{"target": target}}
+		}
+	}
+
+	if len(overrides) == 0 {
+		return ctx, fmt.Errorf("--build-target '%s' given but no service has a build section", target)
+	}
+
+	codec, err := codecs.GetMime(codecs.MimeYAML)
+	if err != nil {
+		return ctx, fmt.Errorf("while getting codec: %w", err)
+	}
+
+	b, err := codec.Marshal(map[string]any{"services": overrides})
+	if err != nil {
+		return ctx, fmt.Errorf("while marshalling the build target override: %w", err)
+	}
+
+	overridePath := filepath.Join(filepath.Dir(composeFilePath), "build-target.yaml")
+	if err := os.WriteFile(overridePath, b, FileMode(ctx)); err != nil {
+		return ctx, fmt.Errorf("while writing the build target override: %w", err)
+	}
+
+	// WriteFile keeps the mode of an existing file.
+	if err := os.Chmod(overridePath, FileMode(ctx)); err != nil {
+		return ctx, fmt.Errorf("while changing the file mode: %w", err)
+	}
+
+	return withOverrideFile(ctx, overridePath), nil
+}
+
+// withBuildKit sets DOCKER_BUILDKIT and COMPOSE_DOCKER_CLI_BUILD for commands with a --buildkit flag.
+// BuildKit is enabled by default, --buildkit wins over octoctl.buildkit which wins over octoctl.composeEnv.
+func withBuildKit(ctx context.Context, cmd *cli.Command, data map[string]any) (context.Context, error) {
+	if !slices.ContainsFunc(cmd.Flags, func(f cli.Flag) bool { return slices.Contains(f.Names(), "buildkit") }) {
+		return ctx, nil
+	}
+
+	enabled := cmd.Bool("buildkit")
+
+	if !cmd.IsSet("buildkit") {
+		raw, ok := octoctlSection(data)["buildkit"]
+		if !ok || raw == nil {
+			// Keep the values of octoctl.composeEnv.
+			return withEnvDefaults(ctx, buildKitEnv(enabled)), nil
+		}
+
+		enabled, ok = raw.(bool)
+		if !ok {
+			return ctx, errors.New("octoctl.buildkit must be a boolean")
+		}
+	}
+
+	return WithEnv(ctx, buildKitEnv(enabled)), nil
+}
+
+// buildKitEnv returns the environment enabling or disabling BuildKit.
+func buildKitEnv(enabled bool) map[string]string {
+	value := "0"
+	if enabled {
+		value = "1"
+	}
+
+	return map[string]string{"DOCKER_BUILDKIT": value, "COMPOSE_DOCKER_CLI_BUILD": value}
+}
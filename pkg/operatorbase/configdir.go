@@ -0,0 +1,101 @@
+package operatorbase
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/go-orb/go-orb/codecs"
+)
+
+// configDirMimes maps the extensions of the files read from a config directory to their codec.
+//
+//nolint:gochecknoglobals
+var configDirMimes = map[string]string{
+	".json": codecs.MimeJSON,
+	".yaml": codecs.MimeYAML,
+	".yml":  codecs.MimeYAML,
+	".toml": codecs.MimeTOML,
+}
+
+// ReadConfigDir reads every .json, .yaml, .yml and .toml file of dir in lexical order
+// and deep merges them into one config, later files win.
+func ReadConfigDir(dir string, maxSize int64) (map[string]any, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("while reading the config directory: %w", err)
+	}
+
+	names := []string{}
+
+	for _, entry := range entries {
+		if _, ok := configDirMimes[strings.ToLower(filepath.Ext(entry.Name()))]; ok && !entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+
+	if len(names) == 0 {
+		return nil, fmt.Errorf("the config directory '%s' has no .json, .yaml, .yml or .toml files", dir)
+	}
+
+	slices.Sort(names)
+
+	result := map[string]any{}
+
+	for _, name := range names {
+		data, err := readConfigDirFile(filepath.Join(dir, name), maxSize)
+		if err != nil {
+			return nil, err
+		}
+
+		mergeConfig(result, data)
+	}
+
+	return result, nil
+}
+
+func readConfigDirFile(path string, maxSize int64) (map[string]any, error) {
+	fp, err := os.Open(path) //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("while opening config file '%s': %w", path, err)
+	}
+	defer fp.Close() //nolint:errcheck
+
+	b, err := readLimited(fp, maxSize)
+	if err != nil {
+		return nil, fmt.Errorf("while reading config file '%s': %w", path, err)
+	}
+
+	codec, err := codecs.GetMime(configDirMimes[strings.ToLower(filepath.Ext(path))])
+	if err != nil {
+		return nil, fmt.Errorf("while getting codec: %w", err)
+	}
+
+	var data map[string]any
+	if err := codec.Unmarshal(b, &data); err != nil {
+		return nil, fmt.Errorf("while unmarshalling '%s': %w", path, err)
+	}
+
+	if data == nil {
+		return nil, fmt.Errorf("config file '%s' is empty", path)
+	}
+
+	return normalize(data).(map[string]any), nil
+}
+
+// mergeConfig deep merges src into dst, maps are merged by key and any other value replaces the one in dst.
+func mergeConfig(dst, src map[string]any) {
+	for key, value := range src {
+		srcMap, srcOK := value.(map[string]any)
+		dstMap, dstOK := dst[key].(map[string]any)
+
+		if srcOK && dstOK {
+			mergeConfig(dstMap, srcMap)
+			continue
+		}
+
+		dst[key] = value
+	}
+}
@@ -0,0 +1,63 @@
+package operatorbase
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/urfave/cli/v3"
+)
+
+// CommandDefaults maps a command name to the default values of its flags,
+// as declared in octoctl.defaults.
+type CommandDefaults map[string]map[string]any
+
+// ParseCommandDefaults parses octoctl.defaults of the config.
+func ParseCommandDefaults(data map[string]any) (CommandDefaults, error) {
+	defaults := CommandDefaults{}
+
+	raw, ok := octoctlSection(data)["defaults"]
+	if !ok || raw == nil {
+		return defaults, nil
+	}
+
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("while encoding octoctl.defaults: %w", err)
+	}
+
+	if err := json.Unmarshal(b, &defaults); err != nil {
+		return nil, fmt.Errorf("octoctl.defaults must be a map of command name to flag values: %w", err)
+	}
+
+	return defaults, nil
+}
+
+// applyCommandDefaults sets the flags of cmd to the defaults of its command name.
+//
+// The precedence is: flags given on the command line (or by their environment
+// variable) > octoctl.defaults of the config > the built-in default of the flag.
+func applyCommandDefaults(cmd *cli.Command, defaults CommandDefaults) error {
+	for name, value := range defaults[cmd.Name] {
+		if !slices.ContainsFunc(cmd.Flags, func(f cli.Flag) bool { return slices.Contains(f.Names(), name) }) {
+			return fmt.Errorf("octoctl.defaults.%s.%s: the %s command has no flag --%s", cmd.Name, name, cmd.Name, name)
+		}
+
+		if cmd.IsSet(name) {
+			continue
+		}
+
+		values := []any{value}
+		if list, ok := value.([]any); ok {
+			values = list
+		}
+
+		for _, v := range values {
+			if err := cmd.Set(name, fmt.Sprint(v)); err != nil {
+				return fmt.Errorf("octoctl.defaults.%s.%s: %w", cmd.Name, name, err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package operatorbase
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+
+	"github.com/go-orb/go-orb/codecs"
+)
+
+// deployedFile is the name of the copy of the last deployed compose file.
+const deployedFile = "deployed.yaml"
+
+// DeployedFilePath returns the path of the last deployed copy of composeFilePath.
+func DeployedFilePath(composeFilePath string) string {
+	return filepath.Join(filepath.Dir(composeFilePath), deployedFile)
+}
+
+// StoreDeployed keeps a copy of composeFilePath as the last deployed compose file.
+func StoreDeployed(composeFilePath string) error {
+	b, err := os.ReadFile(composeFilePath) //nolint:gosec
+	if err != nil {
+		return fmt.Errorf("while reading file '%s': %w", composeFilePath, err)
+	}
+
+	if err := writeComposeSibling(composeFilePath, DeployedFilePath(composeFilePath), b); err != nil {
+		return fmt.Errorf("while storing the deployed compose file: %w", err)
+	}
+
+	return nil
+}
+
+// LoadComposeFile reads a rendered compose file, a missing file gives an empty map.
+func LoadComposeFile(path string) (map[string]any, error) {
+	b, err := os.ReadFile(path) //nolint:gosec
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]any{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("while reading file '%s': %w", path, err)
+	}
+
+	codec, err := codecs.GetMime(codecs.MimeYAML)
+	if err != nil {
+		return nil, fmt.Errorf("while getting codec: %w", err)
+	}
+
+	var data map[string]any
+	if err := codec.Unmarshal(b, &data); err != nil {
+		return nil, fmt.Errorf("while unmarshalling '%s': %w", path, err)
+	}
+
+	return normalize(data).(map[string]any), nil
+}
+
+// ServiceChanges lists the top level keys of a service that changed.
+type ServiceChanges struct {
+	Added    []string `json:"added"`
+	Removed  []string `json:"removed"`
+	Modified []string `json:"modified"`
+}
+
+// ServicesChangeset lists the services that have been added, removed or changed.
+type ServicesChangeset struct {
+	Added   []string                  `json:"added"`
+	Removed []string                  `json:"removed"`
+	Changed map[string]ServiceChanges `json:"changed"`
+}
+
+// Changeset is the difference between two rendered compose files.
+type Changeset struct {
+	Services ServicesChangeset `json:"services"`
+}
+
+// Empty reports whether there are no changes.
+func (c *Changeset) Empty() bool {
+	return len(c.Services.Added) == 0 && len(c.Services.Removed) == 0 && len(c.Services.Changed) == 0
+}
+
+// Diff computes the changeset from the old to the new rendered config.
+func Diff(oldData, newData map[string]any) *Changeset {
+	oldServices, _ := oldData["services"].(map[string]any) //nolint:errcheck
+	newServices, _ := newData["services"].(map[string]any) //nolint:errcheck
+
+	changeset := &Changeset{
+		Services: ServicesChangeset{
+			Added:   []string{},
+			Removed: []string{},
+			Changed: map[string]ServiceChanges{},
+		},
+	}
+
+	for name, newSvc := range newServices {
+		oldSvc, ok := oldServices[name]
+		if !ok {
+			changeset.Services.Added = append(changeset.Services.Added, name)
+			continue
+		}
+
+		if changes, ok := diffService(oldSvc, newSvc); ok {
+			changeset.Services.Changed[name] = changes
+		}
+	}
+
+	for name := range oldServices {
+		if _, ok := newServices[name]; !ok {
+			changeset.Services.Removed = append(changeset.Services.Removed, name)
+		}
+	}
+
+	slices.Sort(changeset.Services.Added)
+	slices.Sort(changeset.Services.Removed)
+
+	return changeset
+}
+
+// diffService compares the top level keys of two services.
+func diffService(oldValue, newValue any) (ServiceChanges, bool) {
+	oldSvc, _ := oldValue.(map[string]any) //nolint:errcheck
+	newSvc, _ := newValue.(map[string]any) //nolint:errcheck
+
+	changes := ServiceChanges{Added: []string{}, Removed: []string{}, Modified: []string{}}
+
+	for key, value := range newSvc {
+		oldKeyValue, ok := oldSvc[key]
+		if !ok {
+			changes.Added = append(changes.Added, key)
+		} else if !reflect.DeepEqual(oldKeyValue, value) {
+			changes.Modified = append(changes.Modified, key)
+		}
+	}
+
+	for key := range oldSvc {
+		if _, ok := newSvc[key]; !ok {
+			changes.Removed = append(changes.Removed, key)
+		}
+	}
+
+	slices.Sort(changes.Added)
+	slices.Sort(changes.Removed)
+	slices.Sort(changes.Modified)
+
+	changed := len(changes.Added) > 0 || len(changes.Removed) > 0 || len(changes.Modified) > 0
+
+	return changes, changed
+}
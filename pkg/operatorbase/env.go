@@ -0,0 +1,66 @@
+package operatorbase
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// EnvKey holds the extra environment variables of child processes.
+type EnvKey struct{}
+
+// WithEnv returns a context whose child processes get env on top of the
+// environment of the operator, later values win.
+func WithEnv(ctx context.Context, env map[string]string) context.Context {
+	merged := map[string]string{}
+	if existing, ok := ctx.Value(EnvKey{}).(map[string]string); ok {
+		maps.Copy(merged, existing)
+	}
+
+	maps.Copy(merged, env)
+
+	return context.WithValue(ctx, EnvKey{}, merged)
+}
+
+// withEnvDefaults is WithEnv, keeping the values already set in ctx.
+func withEnvDefaults(ctx context.Context, env map[string]string) context.Context {
+	merged := maps.Clone(env)
+	if existing, ok := ctx.Value(EnvKey{}).(map[string]string); ok {
+		maps.Copy(merged, existing)
+	}
+
+	return context.WithValue(ctx, EnvKey{}, merged)
+}
+
+// childEnv returns the extra environment of child processes as KEY=VALUE pairs.
+func childEnv(ctx context.Context) []string {
+	env, ok := ctx.Value(EnvKey{}).(map[string]string)
+	if !ok || len(env) == 0 {
+		return nil
+	}
+
+	result := make([]string, 0, len(env))
+	for _, key := range slices.Sorted(maps.Keys(env)) {
+		result = append(result, key+"="+env[key])
+	}
+
+	return result
+}
+
+// ParseKeyValues parses a list of KEY=VALUE pairs.
+func ParseKeyValues(pairs []string) (map[string]string, error) {
+	result := make(map[string]string, len(pairs))
+
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("invalid value '%s', expected KEY=VALUE", pair)
+		}
+
+		result[key] = value
+	}
+
+	return result, nil
+}
@@ -0,0 +1,162 @@
+package operatorbase
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-orb/go-orb/log"
+)
+
+// Variables returns the octoctl.variables map of the config.
+func Variables(data map[string]any) (map[string]string, error) {
+	raw, ok := octoctlSection(data)["variables"]
+	if !ok || raw == nil {
+		return nil, nil //nolint:nilnil
+	}
+
+	rawMap, ok := raw.(map[string]any)
+	if !ok {
+		return nil, errors.New("octoctl.variables must be a map")
+	}
+
+	variables := make(map[string]string, len(rawMap))
+
+	for key, value := range rawMap {
+		switch v := value.(type) {
+		case string:
+			variables[key] = v
+		case bool, int, int64, uint64, float64:
+			variables[key] = fmt.Sprint(v)
+		default:
+			return nil, fmt.Errorf("octoctl.variables.%s must be a scalar value", key)
+		}
+	}
+
+	return variables, nil
+}
+
+// WriteEnvFile writes variables to a .env file in dir, compose loads it from the
+// project directory which defaults to the directory of the compose file.
+// An existing .env file is removed when there are no variables.
+func WriteEnvFile(logger log.Logger, dir string, variables map[string]string, fileMode os.FileMode) error {
+	envFilePath := filepath.Join(dir, ".env")
+
+	if len(variables) == 0 {
+		if err := os.Remove(envFilePath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			logger.Error("Error while removing the env file", "error", err)
+			return fmt.Errorf("while removing file '%s': %w", envFilePath, err)
+		}
+
+		return nil
+	}
+
+	keys := make([]string, 0, len(variables))
+	for key := range variables {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		b.WriteString(key + "=" + strconv.Quote(variables[key]) + "\n")
+	}
+
+	if err := os.WriteFile(envFilePath, []byte(b.String()), fileMode); err != nil {
+		logger.Error("Error while writing the env file", "error", err)
+		return fmt.Errorf("while writing file '%s': %w", envFilePath, err)
+	}
+
+	if err := os.Chmod(envFilePath, fileMode); err != nil {
+		logger.Error("Error while changing the file mode", "error", err)
+		return fmt.Errorf("while changing the file mode: %w", err)
+	}
+
+	return nil
+}
+
+// discoverEnvFile returns the .env file next to the config file,
+// or an empty string if there's none or discovery is disabled.
+func discoverEnvFile(configFile string, discover bool) (string, error) {
+	if !discover || configFile == "-" || configFile == "" || IsConfigURL(configFile) {
+		return "", nil
+	}
+
+	configDir, err := filepath.Abs(filepath.Dir(configFile))
+	if err != nil {
+		return "", fmt.Errorf("while resolving the config directory: %w", err)
+	}
+
+	envFile := filepath.Join(configDir, ".env")
+
+	info, err := os.Stat(envFile)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && info.IsDir()) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("while looking for '%s': %w", envFile, err)
+	}
+
+	return envFile, nil
+}
+
+// EnvFiles returns the env files to pass to compose with --env-file.
+//
+// A .env file next to the config file is used unless discovery is disabled.
+// The rendered .env is passed last whenever variables have been rendered, so the
+// config variables win and don't depend on the project directory of compose.
+func EnvFiles(configFile string, discover bool, renderedEnvFile string, hasVariables bool) ([]string, error) {
+	envFile, err := discoverEnvFile(configFile, discover)
+	if err != nil {
+		return nil, err
+	}
+
+	envFiles := []string{}
+	if envFile != "" {
+		envFiles = append(envFiles, envFile)
+	}
+
+	if hasVariables {
+		envFiles = append(envFiles, renderedEnvFile)
+	}
+
+	return envFiles, nil
+}
+
+// readEnvFile reads the variable names and values of a .env file.
+func readEnvFile(path string) (map[string]string, error) {
+	b, err := os.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("while reading '%s': %w", path, err)
+	}
+
+	result := map[string]string{}
+
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		if !ok {
+			continue
+		}
+
+		value = strings.TrimSpace(value)
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		} else {
+			value = strings.Trim(value, "'")
+		}
+
+		result[strings.TrimSpace(key)] = value
+	}
+
+	return result, nil
+}
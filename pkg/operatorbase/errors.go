@@ -0,0 +1,137 @@
+package operatorbase
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+
+	"github.com/go-orb/go-orb/codecs"
+)
+
+// Stages at which an operator command can fail.
+const (
+	StageReadOnly   = "read-only"
+	StageReadConfig = "read-config"
+	StagePrepare    = "prepare"
+	StageWrite      = "write"
+	StageRun        = "run"
+)
+
+// Error is an error that happened at a given stage of a command.
+type Error struct {
+	Stage    string
+	Service  string
+	ExitCode int
+	Err      error
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	if e.Service != "" {
+		return fmt.Sprintf("%s: service '%s': %s", e.Stage, e.Service, e.Err)
+	}
+
+	return fmt.Sprintf("%s: %s", e.Stage, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// stageError wraps err into an Error of the given stage, unless it already is one.
+func stageError(stage string, err error) error {
+	var opErr *Error
+	if errors.As(err, &opErr) {
+		return err
+	}
+
+	return &Error{Stage: stage, Err: err}
+}
+
+// runError wraps the error of a child process and keeps its exit code.
+func runError(err error) error {
+	opErr := &Error{Stage: StageRun, ExitCode: 1, Err: err}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+		opErr.ExitCode = exitErr.ExitCode()
+	}
+
+	return opErr
+}
+
+// jsonError is the JSON representation of an Error.
+type jsonError struct {
+	Error    string `json:"error"`
+	Stage    string `json:"stage,omitempty"`
+	Service  string `json:"service,omitempty"`
+	ExitCode int    `json:"exit_code,omitempty"`
+}
+
+// ExitCode returns the exit code the process should exit with for err.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var opErr *Error
+	if errors.As(err, &opErr) && opErr.ExitCode > 0 {
+		return opErr.ExitCode
+	}
+
+	return 1
+}
+
+// HandleError reports err in the given format ("text" or "json") to w
+// and returns the exit code the process should exit with.
+//
+// In text format an Error isn't written again as it has already been logged,
+// any other error, or an Error wrapped with more context, is written as is.
+func HandleError(w io.Writer, err error, format string) int {
+	exitCode := ExitCode(err)
+
+	var opErr *Error
+	errors.As(err, &opErr)
+
+	// A wrapped Error, e.g. with a hint, keeps the message of the wrapper.
+	wrapped := opErr != nil && err.Error() != opErr.Error()
+
+	if format != "json" {
+		if opErr == nil || wrapped {
+			_, _ = fmt.Fprintf(w, "Error: %s\n", err)
+		}
+
+		return exitCode
+	}
+
+	out := jsonError{Error: err.Error()}
+	if opErr != nil {
+		message := opErr.Err.Error()
+		if wrapped {
+			message = err.Error()
+		}
+
+		out = jsonError{
+			Error:    message,
+			Stage:    opErr.Stage,
+			Service:  opErr.Service,
+			ExitCode: opErr.ExitCode,
+		}
+	}
+
+	codec, cErr := codecs.GetMime(codecs.MimeJSON)
+	if cErr != nil {
+		return exitCode
+	}
+
+	b, mErr := codec.Marshal(out)
+	if mErr != nil {
+		return exitCode
+	}
+
+	_, _ = w.Write(append(b, '\n'))
+
+	return exitCode
+}
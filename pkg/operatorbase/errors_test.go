@@ -0,0 +1,80 @@
+package operatorbase
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	_ "github.com/go-orb/plugins/codecs/json"
+)
+
+func TestHandleError(t *testing.T) {
+	runErr := &Error{Stage: StageRun, ExitCode: 3, Err: errors.New("exit status 3")}
+
+	tests := []struct {
+		name     string
+		err      error
+		format   string
+		want     string
+		exitCode int
+	}{
+		{name: "logged error in text", err: runErr, format: "text", want: "", exitCode: 3},
+		{name: "plain error in text", err: errors.New("boom"), format: "text", want: "Error: boom\n", exitCode: 1},
+		{
+			name:     "wrapped error in text",
+			err:      fmt.Errorf("hint: %w", runErr),
+			format:   "text",
+			want:     "Error: hint: run: exit status 3\n",
+			exitCode: 3,
+		},
+		{
+			name:     "logged error in json",
+			err:      runErr,
+			format:   "json",
+			want:     `"error":"exit status 3"`,
+			exitCode: 3,
+		},
+		{
+			name:     "wrapped error in json",
+			err:      fmt.Errorf("hint: %w", runErr),
+			format:   "json",
+			want:     `"error":"hint: run: exit status 3"`,
+			exitCode: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			if got := HandleError(&buf, tt.err, tt.format); got != tt.exitCode {
+				t.Errorf("HandleError() = %d, want %d", got, tt.exitCode)
+			}
+
+			if tt.want == "" && buf.Len() > 0 {
+				t.Errorf("HandleError() wrote %q, want nothing", buf.String())
+			} else if !strings.Contains(buf.String(), tt.want) {
+				t.Errorf("HandleError() wrote %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestTLSErrorWriterWrap(t *testing.T) {
+	var buf bytes.Buffer
+
+	w := NewTLSErrorWriter(&buf)
+	if _, err := w.Write([]byte("x509: certificate signed by unknown authority\n")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	var out bytes.Buffer
+
+	HandleError(&out, w.Wrap(runError(errors.New("exit status 1")), []string{"registry.local"}), "json")
+
+	if !strings.Contains(out.String(), "registry.local uses a self-signed certificate") {
+		t.Errorf("HandleError() lost the TLS hint: %s", out.String())
+	}
+}
@@ -0,0 +1,44 @@
+package operatorbase
+
+import (
+	"fmt"
+	"strings"
+)
+
+// eventFilterKeys maps the keys of --filter to docker events filters.
+//
+//nolint:gochecknoglobals
+var eventFilterKeys = map[string]func(value string) string{
+	"type":      func(value string) string { return "event=" + value },
+	"service":   func(value string) string { return "label=com.docker.compose.service=" + value },
+	"container": func(value string) string { return "container=" + value },
+}
+
+// EventFilterArgs validates KEY=VALUE[,VALUE...] filters and returns them as
+// docker events --filter arguments, docker ORs the values of the same key.
+func EventFilterArgs(filters []string) ([]string, error) {
+	args := []string{}
+
+	for _, filter := range filters {
+		key, values, ok := strings.Cut(filter, "=")
+		if !ok || values == "" {
+			return nil, fmt.Errorf("invalid filter '%s', expected KEY=VALUE[,VALUE...]", filter)
+		}
+
+		toDocker, ok := eventFilterKeys[key]
+		if !ok {
+			return nil, fmt.Errorf("invalid filter key '%s', expected type, service or container", key)
+		}
+
+		for _, value := range strings.Split(values, ",") {
+			value = strings.TrimSpace(value)
+			if value == "" {
+				return nil, fmt.Errorf("invalid filter '%s', empty value", filter)
+			}
+
+			args = append(args, "--filter", toDocker(value))
+		}
+	}
+
+	return args, nil
+}
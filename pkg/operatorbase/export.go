@@ -0,0 +1,220 @@
+package operatorbase
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-orb/go-orb/codecs"
+)
+
+// exportArchive collects the files of an exported stack.
+type exportArchive struct {
+	baseDir string
+	files   map[string]string // source path -> path in the archive
+}
+
+// add registers the local file at ref and returns its path within the archive.
+func (a *exportArchive) add(ref string) string {
+	source := ref
+	if !filepath.IsAbs(source) {
+		source = filepath.Join(a.baseDir, source)
+	}
+
+	if archivePath, ok := a.files[source]; ok {
+		return "./" + archivePath
+	}
+
+	archivePath := path.Join("files", fmt.Sprintf("%d-%s", len(a.files), filepath.Base(source)))
+	a.files[source] = archivePath
+
+	return "./" + archivePath
+}
+
+// rewriteFileRefs rewrites the env_file, configs and secrets references of data
+// to paths within the archive, in a stable order so the archive is reproducible.
+func (a *exportArchive) rewriteFileRefs(data map[string]any) {
+	for _, section := range []string{"configs", "secrets"} {
+		entries, _ := data[section].(map[string]any) //nolint:errcheck
+		for _, name := range slices.Sorted(maps.Keys(entries)) {
+			if e, ok := entries[name].(map[string]any); ok {
+				if file, ok := e["file"].(string); ok && file != "" {
+					e["file"] = a.add(file)
+				}
+			}
+		}
+	}
+
+	services, _ := data["services"].(map[string]any) //nolint:errcheck
+	for _, name := range slices.Sorted(maps.Keys(services)) {
+		svc, ok := services[name].(map[string]any)
+		if !ok {
+			continue
+		}
+
+		switch envFile := svc["env_file"].(type) {
+		case string:
+			svc["env_file"] = a.add(envFile)
+		case []any:
+			for i, item := range envFile {
+				switch v := item.(type) {
+				case string:
+					envFile[i] = a.add(v)
+				case map[string]any:
+					if p, ok := v["path"].(string); ok {
+						v["path"] = a.add(p)
+					}
+				}
+			}
+		}
+	}
+}
+
+// composeFile rewrites the references of the compose file at path and returns it encoded.
+func (a *exportArchive) composeFile(path string) ([]byte, error) {
+	data, err := LoadComposeFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	a.rewriteFileRefs(data)
+
+	codec, err := codecs.GetMime(codecs.MimeYAML)
+	if err != nil {
+		return nil, fmt.Errorf("while getting codec: %w", err)
+	}
+
+	b, err := codec.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("while marshalling: %w", err)
+	}
+
+	return b, nil
+}
+
+// Export writes a tarball of the rendered compose file, its base and override files,
+// the local files they reference and the env files of ctx. References are rewritten
+// to be relative within the archive and resolved against the project directory like
+// compose does. With base or override files the .env sets COMPOSE_FILE, so compose
+// layers the files in the same order.
+func Export(ctx context.Context, w io.Writer) error {
+	composeFilePath := ComposeFilePath(ctx)
+	overrides, _ := ctx.Value(OverrideFilesKey{}).([]string) //nolint:errcheck
+
+	archive := &exportArchive{baseDir: ProjectDirectory(ctx), files: map[string]string{}}
+
+	sources := []string{}
+	names := []string{}
+
+	for i, baseFile := range baseFilesFromContext(ctx) {
+		sources = append(sources, baseFile)
+		names = append(names, fmt.Sprintf("base-%d-%s", i, filepath.Base(baseFile)))
+	}
+
+	sources = append(sources, composeFilePath)
+	names = append(names, "compose.yaml")
+
+	for i, override := range overrides {
+		sources = append(sources, override)
+		names = append(names, fmt.Sprintf("override-%d-%s", i, filepath.Base(override)))
+	}
+
+	tw := tar.NewWriter(w)
+
+	for i, source := range sources {
+		b, err := archive.composeFile(source)
+		if err != nil {
+			return err
+		}
+
+		if err := writeTarFile(tw, names[i], b); err != nil {
+			return err
+		}
+	}
+
+	var env []byte
+
+	if len(names) > 1 {
+		env = []byte("COMPOSE_PATH_SEPARATOR=:\nCOMPOSE_FILE=" + strings.Join(names, ":") + "\n")
+	}
+
+	// Without discovered env files compose uses the rendered .env of the project directory,
+	// otherwise the env files are merged in the order compose reads them.
+	envFiles := EnvFilesFromContext(ctx)
+	if len(envFiles) == 0 {
+		envFiles = []string{filepath.Join(filepath.Dir(composeFilePath), ".env")}
+	}
+
+	for _, envFile := range envFiles {
+		content, err := os.ReadFile(envFile) //nolint:gosec
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("while reading file '%s': %w", envFile, err)
+		}
+
+		env = append(env, content...)
+
+		// Keep the last line of a file from running into the first of the next.
+		if len(content) > 0 && content[len(content)-1] != '\n' {
+			env = append(env, '\n')
+		}
+	}
+
+	if len(env) > 0 {
+		if err := writeTarFile(tw, ".env", env); err != nil {
+			return err
+		}
+	}
+
+	files := make(map[string]string, len(archive.files))
+	for source, archivePath := range archive.files {
+		files[archivePath] = source
+	}
+
+	for _, archivePath := range slices.Sorted(maps.Keys(files)) {
+		content, err := os.ReadFile(files[archivePath])
+		if err != nil {
+			return fmt.Errorf("while reading referenced file '%s': %w", files[archivePath], err)
+		}
+
+		if err := writeTarFile(tw, archivePath, content); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("while closing the archive: %w", err)
+	}
+
+	return nil
+}
+
+func writeTarFile(tw *tar.Writer, name string, content []byte) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(content)),
+		ModTime: time.Now(),
+	}
+
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("while writing '%s' to the archive: %w", name, err)
+	}
+
+	if _, err := tw.Write(content); err != nil {
+		return fmt.Errorf("while writing '%s' to the archive: %w", name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package operatorbase
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// readTar returns the names and contents of the entries of an archive, in order.
+func readTar(t *testing.T, b []byte) ([]string, map[string]string) {
+	t.Helper()
+
+	names := []string{}
+	contents := map[string]string{}
+
+	tr := tar.NewReader(bytes.NewReader(b))
+
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("ReadAll() error = %v", err)
+		}
+
+		names = append(names, header.Name)
+		contents[header.Name] = string(content)
+	}
+
+	return names, contents
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
+
+func TestExport(t *testing.T) {
+	dir := t.TempDir()
+
+	composeFilePath := filepath.Join(dir, "compose.yaml")
+	writeTestFile(t, composeFilePath, "services:\n  web:\n    image: nginx\n    env_file: web.env\n  api:\n    image: api\n    env_file: api.env\n")
+	writeTestFile(t, filepath.Join(dir, "base.yaml"), "services:\n  web:\n    restart: always\n")
+	writeTestFile(t, filepath.Join(dir, "override.yaml"), "services:\n  web:\n    ports: ['80:80']\n")
+	writeTestFile(t, filepath.Join(dir, "web.env"), "WEB=1\n")
+	writeTestFile(t, filepath.Join(dir, "api.env"), "API=1\n")
+	writeTestFile(t, filepath.Join(dir, "a.env"), "A=1")
+	writeTestFile(t, filepath.Join(dir, "b.env"), "B=2\n")
+
+	ctx := context.WithValue(context.Background(), ComposeFilePathKey{}, composeFilePath)
+	ctx = context.WithValue(ctx, BaseFilesKey{}, []string{filepath.Join(dir, "base.yaml")})
+	ctx = context.WithValue(ctx, OverrideFilesKey{}, []string{filepath.Join(dir, "override.yaml")})
+	ctx = context.WithValue(ctx, EnvFilesKey{}, []string{filepath.Join(dir, "a.env"), filepath.Join(dir, "b.env")})
+
+	var first []string
+
+	for range 3 {
+		var buf bytes.Buffer
+		if err := Export(ctx, &buf); err != nil {
+			t.Fatalf("Export() error = %v", err)
+		}
+
+		names, contents := readTar(t, buf.Bytes())
+
+		if first == nil {
+			first = names
+		} else if !slices.Equal(names, first) {
+			t.Fatalf("Export() entries = %q, want the same order as %q", names, first)
+		}
+
+		want := []string{"base-0-base.yaml", "compose.yaml", "override-0-override.yaml", ".env", "files/0-api.env", "files/1-web.env"}
+		if !slices.Equal(names, want) {
+			t.Fatalf("Export() entries = %q, want %q", names, want)
+		}
+
+		wantEnv := "COMPOSE_PATH_SEPARATOR=:\nCOMPOSE_FILE=base-0-base.yaml:compose.yaml:override-0-override.yaml\nA=1\nB=2\n"
+		if contents[".env"] != wantEnv {
+			t.Errorf("Export() .env = %q, want %q", contents[".env"], wantEnv)
+		}
+	}
+}
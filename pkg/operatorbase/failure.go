@@ -0,0 +1,21 @@
+package operatorbase
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// OnFailurePolicies are the values accepted by start --on-failure.
+//
+//nolint:gochecknoglobals
+var OnFailurePolicies = []string{"leave", "down", "rollback"}
+
+// ValidateOnFailure returns an error if policy isn't one of OnFailurePolicies.
+func ValidateOnFailure(policy string) error {
+	if !slices.Contains(OnFailurePolicies, policy) {
+		return fmt.Errorf("invalid on-failure policy '%s', expected %s", policy, strings.Join(OnFailurePolicies, ", "))
+	}
+
+	return nil
+}
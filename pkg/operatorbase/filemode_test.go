@@ -0,0 +1,69 @@
+package operatorbase
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/urfave/cli/v3"
+)
+
+func assertMode(t *testing.T, path string, want os.FileMode) {
+	t.Helper()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+
+	if got := info.Mode().Perm(); got != want {
+		t.Errorf("mode of '%s' = %o, want %o", path, got, want)
+	}
+}
+
+func TestWriteConfigFileMode(t *testing.T) {
+	cache := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cache)
+
+	// A project directory created before --file-mode existed.
+	projectDir := filepath.Join(cache, "octocompose", "test")
+	if err := os.MkdirAll(projectDir, 0700); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+
+	var composeFilePath string
+
+	cmd := &cli.Command{
+		Name:  "test",
+		Flags: []cli.Flag{&cli.StringFlag{Name: "compose-file"}},
+		Action: func(_ context.Context, cmd *cli.Command) error {
+			var err error
+			composeFilePath, err = WriteConfig(testLogger(t), cmd, map[string]any{"services": map[string]any{}}, "test", 0640)
+
+			return err
+		},
+	}
+
+	if err := cmd.Run(context.Background(), []string{"test"}); err != nil {
+		t.Fatalf("WriteConfig() error = %v", err)
+	}
+
+	assertMode(t, projectDir, 0750)
+	assertMode(t, composeFilePath, 0640)
+
+	if err := RecordDeploy(composeFilePath); err != nil {
+		t.Fatalf("RecordDeploy() error = %v", err)
+	}
+
+	assertMode(t, filepath.Join(projectDir, deployHashFile), 0640)
+	assertMode(t, DeployedFilePath(composeFilePath), 0640)
+	assertMode(t, historyPath(composeFilePath), 0750)
+
+	versions, err := ListHistory(composeFilePath)
+	if err != nil || len(versions) != 1 {
+		t.Fatalf("ListHistory() = %v, %v, want one version", versions, err)
+	}
+
+	assertMode(t, versions[0].Path, 0640)
+}
@@ -0,0 +1,76 @@
+package operatorbase
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+)
+
+// GrepWriter passes on only the lines matching a pattern,
+// or only the lines not matching it when inverted.
+type GrepWriter struct {
+	w      io.Writer
+	re     *regexp.Regexp
+	invert bool
+	buf    []byte
+}
+
+// NewGrepWriter creates a GrepWriter.
+func NewGrepWriter(w io.Writer, re *regexp.Regexp, invert bool) *GrepWriter {
+	return &GrepWriter{w: w, re: re, invert: invert}
+}
+
+// Write implements io.Writer, partial lines are buffered until they are complete.
+func (g *GrepWriter) Write(p []byte) (int, error) {
+	g.buf = append(g.buf, p...)
+
+	idx := bytes.LastIndexByte(g.buf, '\n')
+	if idx < 0 {
+		return len(p), nil
+	}
+
+	err := g.writeMatching(g.buf[:idx+1])
+
+	g.buf = append(g.buf[:0], g.buf[idx+1:]...)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// Flush filters a remaining partial line.
+func (g *GrepWriter) Flush() error {
+	if len(g.buf) == 0 {
+		return nil
+	}
+
+	err := g.writeMatching(g.buf)
+
+	g.buf = g.buf[:0]
+
+	return err
+}
+
+func (g *GrepWriter) writeMatching(lines []byte) error {
+	var b bytes.Buffer
+
+	for _, line := range bytes.SplitAfter(lines, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+
+		if g.re.Match(bytes.TrimSuffix(line, []byte("\n"))) != g.invert {
+			b.Write(line)
+		}
+	}
+
+	if b.Len() == 0 {
+		return nil
+	}
+
+	_, err := g.w.Write(b.Bytes())
+
+	return err
+}
@@ -0,0 +1,98 @@
+package operatorbase
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// deployHashFile is the name of the file next to the compose file
+// which holds the hash of the last deployed compose file.
+const deployHashFile = "deployed.sha256"
+
+// FileHash returns the hex encoded sha256 of the file at path.
+func FileHash(path string) (string, error) {
+	b, err := os.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return "", fmt.Errorf("while reading file '%s': %w", path, err)
+	}
+
+	sum := sha256.Sum256(b)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// LastDeployHash returns the hash stored by the last deploy of composeFilePath,
+// or an empty string if there hasn't been one.
+func LastDeployHash(composeFilePath string) (string, error) {
+	b, err := os.ReadFile(filepath.Join(filepath.Dir(composeFilePath), deployHashFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("while reading the last deploy hash: %w", err)
+	}
+
+	return strings.TrimSpace(string(b)), nil
+}
+
+// LastDeployTime returns the time of the last deploy of composeFilePath,
+// or the zero time if there hasn't been one.
+func LastDeployTime(composeFilePath string) (time.Time, error) {
+	info, err := os.Stat(filepath.Join(filepath.Dir(composeFilePath), deployHashFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return time.Time{}, nil
+	} else if err != nil {
+		return time.Time{}, fmt.Errorf("while reading the last deploy time: %w", err)
+	}
+
+	return info.ModTime(), nil
+}
+
+// StoreDeployHash stores hash as the last deployed hash of composeFilePath.
+func StoreDeployHash(composeFilePath, hash string) error {
+	if err := writeComposeSibling(composeFilePath, filepath.Join(filepath.Dir(composeFilePath), deployHashFile), []byte(hash+"\n")); err != nil {
+		return fmt.Errorf("while writing the deploy hash: %w", err)
+	}
+
+	return nil
+}
+
+// RecordDeploy stores the hash and a copy of composeFilePath after a successful deploy
+// and adds it to the history.
+func RecordDeploy(composeFilePath string) error {
+	if err := recordDeployState(composeFilePath); err != nil {
+		return err
+	}
+
+	return StoreHistory(composeFilePath)
+}
+
+// RecordRollback stores the hash and a copy of composeFilePath after rolling back to version,
+// which becomes the deployed version of the history without adding a new one.
+func RecordRollback(composeFilePath string, version HistoryVersion) error {
+	if err := recordDeployState(composeFilePath); err != nil {
+		return err
+	}
+
+	return setCurrentHistory(composeFilePath, version.Timestamp)
+}
+
+// recordDeployState stores the hash and a copy of the deployed composeFilePath.
+func recordDeployState(composeFilePath string) error {
+	hash, err := FileHash(composeFilePath)
+	if err != nil {
+		return err
+	}
+
+	if err := StoreDeployHash(composeFilePath, hash); err != nil {
+		return err
+	}
+
+	return StoreDeployed(composeFilePath)
+}
@@ -0,0 +1,102 @@
+package operatorbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-orb/go-orb/codecs"
+)
+
+// HealthState is the liveness and the last reconcile result of the daemon.
+type HealthState struct {
+	mu sync.Mutex
+
+	StartedAt     time.Time `json:"startedAt"`
+	LastReconcile time.Time `json:"lastReconcile,omitempty"`
+	LastError     string    `json:"lastError,omitempty"`
+	Reconciles    int       `json:"reconciles"`
+	Failures      int       `json:"failures"`
+}
+
+// NewHealthState creates a HealthState started now.
+func NewHealthState() *HealthState {
+	return &HealthState{StartedAt: time.Now().UTC()}
+}
+
+// Record stores the result of a reconcile.
+func (h *HealthState) Record(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.LastReconcile = time.Now().UTC()
+	h.Reconciles++
+	h.LastError = ""
+
+	if err != nil {
+		h.Failures++
+		h.LastError = err.Error()
+	}
+}
+
+// ServeHTTP implements http.Handler, it responds with the state as JSON,
+// with status 503 when the last reconcile failed.
+func (h *HealthState) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	codec, err := codecs.GetMime(codecs.MimeJSON)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.mu.Lock()
+	b, err := codec.Marshal(h)
+	failed := h.LastError != ""
+	h.mu.Unlock()
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if failed {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	_, _ = w.Write(append(b, '\n'))
+}
+
+// ListenHealth opens the listener of the health endpoint.
+func ListenHealth(addr string) (net.Listener, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("while listening on '%s': %w", addr, err)
+	}
+
+	return listener, nil
+}
+
+// ServeHealth serves h on listener until ctx is done.
+func ServeHealth(ctx context.Context, listener net.Listener, h *HealthState) error {
+	server := &http.Server{Handler: h, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+
+		_ = server.Shutdown(shutdownCtx) //nolint:contextcheck
+	}()
+
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("while serving the health endpoint: %w", err)
+	}
+
+	return nil
+}
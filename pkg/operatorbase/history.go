@@ -0,0 +1,208 @@
+package operatorbase
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// historyDir is the directory next to the compose file which keeps every deployed version.
+	historyDir = "history"
+
+	// historyCurrentFile is the file in the history directory which names the deployed version.
+	historyCurrentFile = "current"
+
+	// historyLimit is the number of versions the history keeps.
+	historyLimit = 20
+
+	// HistoryTimeFormat is the format of the timestamps of the history versions,
+	// its fixed width keeps the file names sorted by time.
+	HistoryTimeFormat = "20060102T150405.000000000Z"
+
+	// legacyHistoryTimeFormat is the format of versions stored before HistoryTimeFormat.
+	legacyHistoryTimeFormat = "20060102T150405Z"
+)
+
+// HistoryVersion is a deployed version of a compose file.
+type HistoryVersion struct {
+	Timestamp string    `json:"timestamp"`
+	Time      time.Time `json:"time"`
+	Path      string    `json:"path"`
+	Current   bool      `json:"current"`
+}
+
+// historyPath returns the history directory of composeFilePath.
+func historyPath(composeFilePath string) string {
+	return filepath.Join(filepath.Dir(composeFilePath), historyDir)
+}
+
+// StoreHistory keeps a copy of composeFilePath as compose.<timestamp>.yaml in the history,
+// marks it as the deployed version and removes the versions beyond historyLimit.
+func StoreHistory(composeFilePath string) error {
+	b, err := os.ReadFile(composeFilePath) //nolint:gosec
+	if err != nil {
+		return fmt.Errorf("while reading file '%s': %w", composeFilePath, err)
+	}
+
+	dir := historyPath(composeFilePath)
+	if err := os.MkdirAll(dir, dirMode(composeFileMode(composeFilePath))); err != nil {
+		return fmt.Errorf("while creating the history directory: %w", err)
+	}
+
+	if err := os.Chmod(dir, dirMode(composeFileMode(composeFilePath))); err != nil {
+		return fmt.Errorf("while changing the mode of the history directory: %w", err)
+	}
+
+	timestamp := time.Now().UTC().Format(HistoryTimeFormat)
+	if err := writeComposeSibling(composeFilePath, filepath.Join(dir, "compose."+timestamp+".yaml"), b); err != nil {
+		return fmt.Errorf("while storing the compose file in the history: %w", err)
+	}
+
+	if err := setCurrentHistory(composeFilePath, timestamp); err != nil {
+		return err
+	}
+
+	return pruneHistory(composeFilePath)
+}
+
+// setCurrentHistory marks the version with timestamp as the deployed one.
+func setCurrentHistory(composeFilePath, timestamp string) error {
+	if err := writeComposeSibling(composeFilePath, filepath.Join(historyPath(composeFilePath), historyCurrentFile), []byte(timestamp+"\n")); err != nil {
+		return fmt.Errorf("while marking the deployed version: %w", err)
+	}
+
+	return nil
+}
+
+// pruneHistory removes the oldest versions beyond historyLimit, never the deployed one.
+func pruneHistory(composeFilePath string) error {
+	versions, err := ListHistory(composeFilePath)
+	if err != nil {
+		return err
+	}
+
+	for _, version := range versions[:max(0, len(versions)-historyLimit)] {
+		if version.Current {
+			continue
+		}
+
+		if err := os.Remove(version.Path); err != nil {
+			return fmt.Errorf("while removing the history version '%s': %w", version.Timestamp, err)
+		}
+	}
+
+	return nil
+}
+
+// ListHistory returns the deployed versions of composeFilePath, oldest first.
+// Without a marked version the newest one is the deployed one.
+func ListHistory(composeFilePath string) ([]HistoryVersion, error) {
+	dir := historyPath(composeFilePath)
+
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []HistoryVersion{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("while reading the history: %w", err)
+	}
+
+	current := ""
+
+	b, err := os.ReadFile(filepath.Join(dir, historyCurrentFile)) //nolint:gosec
+	if err == nil {
+		current = strings.TrimSpace(string(b))
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("while reading the deployed version: %w", err)
+	}
+
+	versions := []HistoryVersion{}
+
+	for _, entry := range entries {
+		timestamp, ok := strings.CutPrefix(entry.Name(), "compose.")
+		if !ok {
+			continue
+		}
+
+		timestamp, ok = strings.CutSuffix(timestamp, ".yaml")
+		if !ok {
+			continue
+		}
+
+		t, err := time.Parse(HistoryTimeFormat, timestamp)
+		if err != nil {
+			t, err = time.Parse(legacyHistoryTimeFormat, timestamp)
+			if err != nil {
+				continue
+			}
+		}
+
+		versions = append(versions, HistoryVersion{
+			Timestamp: timestamp,
+			Time:      t,
+			Path:      filepath.Join(dir, entry.Name()),
+			Current:   timestamp == current,
+		})
+	}
+
+	slices.SortFunc(versions, func(a, b HistoryVersion) int {
+		return a.Time.Compare(b.Time)
+	})
+
+	if len(versions) > 0 && !slices.ContainsFunc(versions, func(v HistoryVersion) bool { return v.Current }) {
+		versions[len(versions)-1].Current = true
+	}
+
+	return versions, nil
+}
+
+// CurrentHistory returns the deployed version of composeFilePath, ok is false without history.
+func CurrentHistory(composeFilePath string) (HistoryVersion, bool, error) {
+	versions, err := ListHistory(composeFilePath)
+	if err != nil {
+		return HistoryVersion{}, false, err
+	}
+
+	idx := slices.IndexFunc(versions, func(v HistoryVersion) bool { return v.Current })
+	if idx < 0 {
+		return HistoryVersion{}, false, nil
+	}
+
+	return versions[idx], true, nil
+}
+
+// PreviousHistory returns the version deployed before the deployed version of composeFilePath,
+// ok is false when there's none.
+func PreviousHistory(composeFilePath string) (HistoryVersion, bool, error) {
+	versions, err := ListHistory(composeFilePath)
+	if err != nil {
+		return HistoryVersion{}, false, err
+	}
+
+	idx := slices.IndexFunc(versions, func(v HistoryVersion) bool { return v.Current })
+	if idx < 1 {
+		return HistoryVersion{}, false, nil
+	}
+
+	return versions[idx-1], true, nil
+}
+
+// RestoreHistory makes version the current compose file at composeFilePath.
+func RestoreHistory(composeFilePath string, version HistoryVersion) error {
+	b, err := os.ReadFile(version.Path)
+	if err != nil {
+		return fmt.Errorf("while reading the history version '%s': %w", version.Timestamp, err)
+	}
+
+	// The compose file keeps its mode.
+	if err := os.WriteFile(composeFilePath, b, 0600); err != nil {
+		return fmt.Errorf("while restoring the history version '%s': %w", version.Timestamp, err)
+	}
+
+	return nil
+}
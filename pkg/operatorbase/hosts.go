@@ -0,0 +1,75 @@
+package operatorbase
+
+import (
+	"fmt"
+	"strings"
+)
+
+// parseHostEntry splits a host:ip (or host=ip) entry.
+func parseHostEntry(entry string) (string, string, error) {
+	idx := strings.IndexAny(entry, ":=")
+	if idx <= 0 || idx == len(entry)-1 {
+		return "", "", fmt.Errorf("invalid host entry '%s', expected host:ip", entry)
+	}
+
+	return entry[:idx], entry[idx+1:], nil
+}
+
+// addExtraHosts merges hosts into the extra_hosts of svc, hosts the service already maps are kept.
+func addExtraHosts(svc map[string]any, hosts []string) error {
+	if len(hosts) == 0 {
+		return nil
+	}
+
+	existing := map[string]bool{}
+
+	switch extraHosts := svc["extra_hosts"].(type) {
+	case map[string]any:
+		for host := range extraHosts {
+			existing[host] = true
+		}
+
+		for _, entry := range hosts {
+			host, ip, err := parseHostEntry(entry)
+			if err != nil {
+				return err
+			}
+
+			if !existing[host] {
+				extraHosts[host] = ip
+				existing[host] = true
+			}
+		}
+
+		return nil
+	case []any:
+		for _, item := range extraHosts {
+			if host, _, err := parseHostEntry(fmt.Sprint(item)); err == nil {
+				existing[host] = true
+			}
+		}
+	case nil:
+	default:
+		return fmt.Errorf("extra_hosts must be a list or a map, got %T", extraHosts)
+	}
+
+	list, _ := svc["extra_hosts"].([]any) //nolint:errcheck
+
+	for _, entry := range hosts {
+		host, ip, err := parseHostEntry(entry)
+		if err != nil {
+			return err
+		}
+
+		if existing[host] {
+			continue
+		}
+
+		list = append(list, host+":"+ip)
+		existing[host] = true
+	}
+
+	svc["extra_hosts"] = list
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package operatorbase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync/atomic"
+
+	"github.com/go-orb/go-orb/log"
+)
+
+// tlsErrorMarkers are the messages docker prints when a registry's certificate isn't trusted.
+//
+//nolint:gochecknoglobals
+var tlsErrorMarkers = [][]byte{
+	[]byte("x509: "),
+	[]byte("tls: failed to verify certificate"),
+	[]byte("server gave HTTP response to HTTPS client"),
+}
+
+// registryConfig is the part of docker info's RegistryConfig the operator uses.
+type registryConfig struct {
+	InsecureRegistryCIDRs []string `json:"InsecureRegistryCIDRs"`
+	IndexConfigs          map[string]struct {
+		Secure bool `json:"Secure"`
+	} `json:"IndexConfigs"`
+}
+
+// isInsecure reports whether the daemon talks to registry without TLS verification.
+func (cfg registryConfig) isInsecure(registry string) bool {
+	if index, ok := cfg.IndexConfigs[registry]; ok && !index.Secure {
+		return true
+	}
+
+	host := registry
+	if h, _, err := net.SplitHostPort(registry); err == nil {
+		host = h
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	for _, cidr := range cfg.InsecureRegistryCIDRs {
+		if _, ipNet, err := net.ParseCIDR(cidr); err == nil && ipNet.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CheckInsecureRegistries warns about every registry the docker daemon isn't configured
+// to treat as insecure, pulls from them fail when they use a self-signed certificate.
+func CheckInsecureRegistries(ctx context.Context, registries []string) {
+	if len(registries) == 0 {
+		return
+	}
+
+	logger := ctx.Value(LoggerKey{}).(log.Logger)
+	composeCommand := ctx.Value(ComposeCommandKey{}).([]string)
+
+	if DetectBackend(composeCommand) != BackendDocker {
+		return
+	}
+
+	var buf bytes.Buffer
+
+	args := []string{EngineCommand(composeCommand), "info", "--format", "{{json .RegistryConfig}}"}
+	if err := RunCmd(context.WithValue(ctx, StdoutKey{}, &buf), args); err != nil {
+		logger.Warn("Unable to read the registry config of the docker daemon", "error", err)
+		return
+	}
+
+	var cfg registryConfig
+	if err := json.Unmarshal(buf.Bytes(), &cfg); err != nil {
+		logger.Warn("Unable to parse the registry config of the docker daemon", "error", err)
+		return
+	}
+
+	for _, registry := range registries {
+		if !cfg.isInsecure(registry) {
+			logger.Warn("The docker daemon doesn't treat the registry as insecure, "+
+				"add it to insecure-registries in /etc/docker/daemon.json "+
+				"or install its CA certificate in /etc/docker/certs.d/<registry>/ca.crt",
+				"registry", registry)
+		}
+	}
+}
+
+// TLSErrorWriter passes output on and records whether it contained a TLS certificate error.
+type TLSErrorWriter struct {
+	w     io.Writer
+	found atomic.Bool
+}
+
+// NewTLSErrorWriter creates a TLSErrorWriter.
+func NewTLSErrorWriter(w io.Writer) *TLSErrorWriter {
+	return &TLSErrorWriter{w: w}
+}
+
+// Write implements io.Writer.
+func (t *TLSErrorWriter) Write(p []byte) (int, error) {
+	for _, marker := range tlsErrorMarkers {
+		if bytes.Contains(p, marker) {
+			t.found.Store(true)
+		}
+	}
+
+	return t.w.Write(p)
+}
+
+// Wrap returns err with a hint about self-signed registries when the output contained a TLS error.
+func (t *TLSErrorWriter) Wrap(err error, registries []string) error {
+	if err == nil || !t.found.Load() {
+		return err
+	}
+
+	hint := "a registry"
+	if len(registries) > 0 {
+		hint = strings.Join(registries, ", ")
+	}
+
+	return fmt.Errorf("pulling failed due to a TLS error; if %s uses a self-signed certificate, "+
+		"add it to insecure-registries in /etc/docker/daemon.json or install its CA certificate "+
+		"in /etc/docker/certs.d/<registry>/ca.crt: %w", hint, err)
+}
@@ -0,0 +1,95 @@
+package operatorbase
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// interpolationRe matches $$, ${NAME...} and $NAME references.
+//
+//nolint:gochecknoglobals
+var interpolationRe = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)([^}]*)\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+
+// undefinedVariables returns the variables referenced in s which are neither
+// defined nor have a default value.
+func undefinedVariables(s string, defined map[string]bool) []string {
+	undefined := []string{}
+
+	for _, match := range interpolationRe.FindAllStringSubmatch(s, -1) {
+		if match[0] == "$$" {
+			continue
+		}
+
+		name, modifier := match[1], match[2]
+		if name == "" {
+			name = match[3]
+		}
+
+		// ${NAME:-default}, ${NAME-default} and ${NAME:+alt} don't need NAME.
+		if strings.HasPrefix(modifier, ":-") || strings.HasPrefix(modifier, "-") || strings.HasPrefix(modifier, ":+") || strings.HasPrefix(modifier, "+") {
+			continue
+		}
+
+		if !defined[name] {
+			undefined = append(undefined, name)
+		}
+	}
+
+	return undefined
+}
+
+// checkInterpolation walks value and returns an error for every
+// undefined variable with the path where it's referenced.
+func checkInterpolation(path string, value any, defined map[string]bool) []error {
+	var errs []error
+
+	switch v := value.(type) {
+	case string:
+		for _, name := range undefinedVariables(v, defined) {
+			errs = append(errs, fmt.Errorf("undefined variable '%s' in %s", name, path))
+		}
+	case map[string]any:
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			errs = append(errs, checkInterpolation(path+"."+key, v[key], defined)...)
+		}
+	case []any:
+		for i, item := range v {
+			errs = append(errs, checkInterpolation(fmt.Sprintf("%s[%d]", path, i), item, defined)...)
+		}
+	}
+
+	return errs
+}
+
+// CheckInterpolation returns an error naming every variable the services reference
+// which isn't defined in the environment, the variables or the env files.
+func CheckInterpolation(services map[string]any, variables map[string]string, envFiles []string) error {
+	defined := map[string]bool{}
+
+	for _, env := range os.Environ() {
+		name, _, _ := strings.Cut(env, "=")
+		defined[name] = true
+	}
+
+	for name := range variables {
+		defined[name] = true
+	}
+
+	for _, envFile := range envFiles {
+		values, err := readEnvFile(envFile)
+		if err != nil {
+			return err
+		}
+
+		for name := range values {
+			defined[name] = true
+		}
+	}
+
+	return errors.Join(checkInterpolation("services", services, defined)...)
+}
@@ -0,0 +1,70 @@
+package operatorbase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/octocompose/octoctl/pkg/octoconfig"
+	"github.com/urfave/cli/v3"
+)
+
+// strictConfig returns a config whose service references ${OCTOCOMPOSE_TEST_X}.
+func strictConfig(octoctl map[string]any) map[string]any {
+	return map[string]any{
+		"name":    "test",
+		"octoctl": octoctl,
+		"services": map[string]any{
+			"web": map[string]any{
+				"environment": map[string]any{"X": "${OCTOCOMPOSE_TEST_X}"},
+			},
+		},
+	}
+}
+
+// prepareStrict runs PrepareConfig with --env-substitution-strict and the extra args.
+func prepareStrict(t *testing.T, data map[string]any, args ...string) error {
+	t.Helper()
+
+	repo := octoconfig.Repo{Services: map[string]octoconfig.RepoService{
+		"web": {Docker: &octoconfig.RepoDocker{Registry: "docker.io", Image: "library/nginx", Tag: "latest"}},
+	}}
+
+	var prepareErr error
+
+	cmd := &cli.Command{
+		Name: "test",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "env-substitution-strict"},
+			&cli.BoolFlag{Name: "no-env-file-discovery"},
+			&cli.StringSliceFlag{Name: "set"},
+		},
+		Action: func(_ context.Context, cmd *cli.Command) error {
+			_, prepareErr = PrepareConfig(testLogger(t), cmd, data, repo)
+			return nil
+		},
+	}
+
+	if err := cmd.Run(context.Background(), append([]string{"test", "--env-substitution-strict", "--no-env-file-discovery"}, args...)); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	return prepareErr
+}
+
+func TestPrepareConfigStrictInterpolation(t *testing.T) {
+	if err := prepareStrict(t, strictConfig(map[string]any{})); err == nil {
+		t.Errorf("PrepareConfig() accepted an undefined variable")
+	}
+
+	if err := prepareStrict(t, strictConfig(map[string]any{"composeEnv": map[string]any{"OCTOCOMPOSE_TEST_X": "1"}})); err != nil {
+		t.Errorf("PrepareConfig() with octoctl.composeEnv error = %v", err)
+	}
+
+	if err := prepareStrict(t, strictConfig(map[string]any{"variables": map[string]any{"OCTOCOMPOSE_TEST_X": "1"}})); err != nil {
+		t.Errorf("PrepareConfig() with octoctl.variables error = %v", err)
+	}
+
+	if err := prepareStrict(t, strictConfig(map[string]any{}), "--set", "OCTOCOMPOSE_TEST_X=1"); err != nil {
+		t.Errorf("PrepareConfig() with --set error = %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package operatorbase
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// CacheRoot returns the directory holding the rendered files of all projects.
+func CacheRoot() (string, error) {
+	userCacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("while getting cache directory: %w", err)
+	}
+
+	return filepath.Join(userCacheDir, "octocompose"), nil
+}
+
+// ManagedProject is a project found in the cache root.
+type ManagedProject struct {
+	Name        string    `json:"name"`
+	ComposeFile string    `json:"composeFile"`
+	LastDeploy  time.Time `json:"lastDeploy"`
+}
+
+// ListManagedProjects returns the projects in the cache root.
+// The last deploy time is the time the deployed copy of the compose file was written,
+// or the modification time of the compose file for projects which haven't been started.
+func ListManagedProjects() ([]ManagedProject, error) {
+	root, err := CacheRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(root)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []ManagedProject{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("while reading the cache directory: %w", err)
+	}
+
+	projects := []ManagedProject{}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		composeFile := filepath.Join(root, entry.Name(), "compose.yaml")
+
+		info, err := os.Stat(DeployedFilePath(composeFile))
+		if errors.Is(err, fs.ErrNotExist) {
+			info, err = os.Stat(composeFile)
+		}
+
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("while reading project '%s': %w", entry.Name(), err)
+		}
+
+		projects = append(projects, ManagedProject{
+			Name:        entry.Name(),
+			ComposeFile: composeFile,
+			LastDeploy:  info.ModTime(),
+		})
+	}
+
+	return projects, nil
+}
+
+// PurgeProject removes the cache directory of the project whose compose file is composeFilePath.
+// It refuses to remove anything outside of the cache root.
+func PurgeProject(composeFilePath string) error {
+	root, err := CacheRoot()
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(composeFilePath)
+
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") || strings.ContainsRune(rel, filepath.Separator) {
+		return fmt.Errorf("refusing to purge '%s', it's not a project directory in '%s'", dir, root)
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("while removing '%s': %w", dir, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package operatorbase
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// LimitWriter passes at most limit bytes to the underlying writer
+// and calls onLimit once when the limit has been reached.
+type LimitWriter struct {
+	w       io.Writer
+	limit   int64
+	written int64
+	onLimit func()
+	reached atomic.Bool
+}
+
+// NewLimitWriter creates a LimitWriter.
+func NewLimitWriter(w io.Writer, limit int64, onLimit func()) *LimitWriter {
+	return &LimitWriter{w: w, limit: limit, onLimit: onLimit}
+}
+
+// Write implements io.Writer, writes past the limit are discarded.
+func (l *LimitWriter) Write(p []byte) (int, error) {
+	if l.reached.Load() {
+		return len(p), nil
+	}
+
+	if remaining := l.limit - l.written; int64(len(p)) >= remaining {
+		n, err := l.w.Write(p[:remaining])
+		l.written += int64(n)
+
+		if l.reached.CompareAndSwap(false, true) && l.onLimit != nil {
+			l.onLimit()
+		}
+
+		if err != nil {
+			return n, err
+		}
+
+		return len(p), nil
+	}
+
+	n, err := l.w.Write(p)
+	l.written += int64(n)
+
+	return n, err
+}
+
+// Reached reports whether the limit has been reached.
+func (l *LimitWriter) Reached() bool {
+	return l.reached.Load()
+}
@@ -0,0 +1,92 @@
+package operatorbase
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// outputMu serializes the writes of all lineWriters.
+//
+//nolint:gochecknoglobals
+var outputMu sync.Mutex
+
+// lineWriter buffers writes and passes them on in whole lines,
+// so the output of concurrent commands doesn't interleave within a line.
+// When a prefix is set every line gets prefixed with it.
+type lineWriter struct {
+	out    io.Writer
+	prefix []byte
+	buf    []byte
+}
+
+func newLineWriter(out io.Writer) *lineWriter {
+	return &lineWriter{out: out}
+}
+
+func newPrefixWriter(out io.Writer, prefix string) *lineWriter {
+	return &lineWriter{out: out, prefix: []byte(prefix)}
+}
+
+// Write implements io.Writer.
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+
+	idx := bytes.LastIndexByte(w.buf, '\n')
+	if idx < 0 {
+		return len(p), nil
+	}
+
+	err := w.writeOut(w.buf[:idx+1])
+
+	w.buf = append(w.buf[:0], w.buf[idx+1:]...)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// Flush writes out a remaining partial line.
+func (w *lineWriter) Flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+
+	err := w.writeOut(w.buf)
+
+	w.buf = w.buf[:0]
+
+	return err
+}
+
+func (w *lineWriter) writeOut(lines []byte) error {
+	if len(w.prefix) > 0 {
+		var b bytes.Buffer
+
+		for _, line := range bytes.SplitAfter(lines, []byte("\n")) {
+			if len(line) == 0 {
+				continue
+			}
+
+			b.Write(w.prefix)
+			b.Write(line)
+		}
+
+		lines = b.Bytes()
+	}
+
+	// A nested lineWriter takes the lock itself once it writes out.
+	if _, ok := w.out.(*lineWriter); ok {
+		_, err := w.out.Write(lines)
+		return err
+	}
+
+	outputMu.Lock()
+	defer outputMu.Unlock()
+
+	_, err := w.out.Write(lines)
+
+	return err
+}
@@ -0,0 +1,65 @@
+package operatorbase
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestLineWriterNested(t *testing.T) {
+	var out bytes.Buffer
+
+	prefix := newPrefixWriter(&out, "[a] ")
+	w := newLineWriter(prefix)
+
+	done := make(chan error, 1)
+
+	go func() {
+		if _, err := w.Write([]byte("hello\nwor")); err != nil {
+			done <- err
+			return
+		}
+
+		if _, err := w.Write([]byte("ld\n")); err != nil {
+			done <- err
+			return
+		}
+
+		done <- prefix.Flush()
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Write() of a nested lineWriter deadlocked")
+	}
+
+	if got, want := out.String(), "[a] hello\n[a] world\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestLineWriterPartialLine(t *testing.T) {
+	var out bytes.Buffer
+
+	w := newPrefixWriter(&out, "[b] ")
+
+	if _, err := w.Write([]byte("one\ntwo")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	if got, want := out.String(), "[b] one\n"; got != want {
+		t.Fatalf("output before Flush() = %q, want %q", got, want)
+	}
+
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	if got, want := out.String(), "[b] one\n[b] two"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
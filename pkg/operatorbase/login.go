@@ -0,0 +1,117 @@
+package operatorbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-orb/go-orb/log"
+	"github.com/urfave/cli/v3"
+)
+
+// RegistryLogin runs docker login with credentials read from the environment variables
+// named by the registry-user-env and registry-pass-env flags.
+// It does nothing when no registry has been given.
+func RegistryLogin(ctx context.Context, cmd *cli.Command) error {
+	registry := cmd.String("registry")
+	if registry == "" {
+		return nil
+	}
+
+	logger := ctx.Value(LoggerKey{}).(log.Logger)
+
+	if cmd.Bool("dry-run") {
+		logger.Info("Skipping the registry login in dry-run mode", "registry", registry)
+		return nil
+	}
+
+	userEnv := cmd.String("registry-user-env")
+	passEnv := cmd.String("registry-pass-env")
+
+	if userEnv == "" || passEnv == "" {
+		logger.Error("Registry login requires --registry-user-env and --registry-pass-env")
+		return errors.New("registry login requires --registry-user-env and --registry-pass-env")
+	}
+
+	user, ok := os.LookupEnv(userEnv)
+	if !ok || user == "" {
+		logger.Error("Environment variable is not set", "name", userEnv)
+		return fmt.Errorf("environment variable '%s' is not set", userEnv)
+	}
+
+	password, ok := os.LookupEnv(passEnv)
+	if !ok || password == "" {
+		logger.Error("Environment variable is not set", "name", passEnv)
+		return fmt.Errorf("environment variable '%s' is not set", passEnv)
+	}
+
+	retries := int(cmd.Int("registry-login-retries"))
+	if retries < 0 {
+		return errors.New("--registry-login-retries must not be negative")
+	}
+
+	return LoginRetry(ctx, registry, user, password, cmd.Duration("registry-login-timeout"), retries)
+}
+
+// LoginRetry runs Login with a timeout per attempt and retries it with
+// an exponential backoff, starting at a second.
+func LoginRetry(ctx context.Context, registry, user, password string, timeout time.Duration, retries int) error {
+	logger := ctx.Value(LoggerKey{}).(log.Logger)
+	backoff := time.Second
+
+	var err error
+
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			logger.Warn("Login failed, retrying", "registry", registry, "attempt", attempt, "retries", retries, "error", err)
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+
+			backoff *= 2
+		}
+
+		err = loginWithTimeout(ctx, registry, user, password, timeout)
+		if err == nil {
+			return nil
+		}
+	}
+
+	logger.Error("Giving up logging in", "registry", registry, "attempts", retries+1)
+
+	return fmt.Errorf("login to '%s' failed after %d attempts: %w", registry, retries+1, err)
+}
+
+func loginWithTimeout(ctx context.Context, registry, user, password string, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	return Login(ctx, registry, user, password)
+}
+
+// Login runs docker login against registry, the password is passed on stdin
+// so it never shows up in the process list.
+func Login(ctx context.Context, registry, user, password string) error {
+	logger := ctx.Value(LoggerKey{}).(log.Logger)
+
+	logger.Debug("Logging in", "registry", registry, "user", user)
+
+	ctx = context.WithValue(ctx, StdinKey{}, strings.NewReader(password))
+
+	if err := RunDocker(ctx, []string{"login", "--username", user, "--password-stdin", registry}); err != nil {
+		logger.Error("Error while logging in", "registry", registry, "error", err)
+		return fmt.Errorf("while logging in to '%s': %w", registry, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,230 @@
+package operatorbase
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// dependsOnConditions are the conditions compose accepts in depends_on.
+//
+//nolint:gochecknoglobals
+var dependsOnConditions = map[string]bool{
+	"service_started":                true,
+	"service_healthy":                true,
+	"service_completed_successfully": true,
+}
+
+// DependsOn maps a service to the condition to wait for.
+//
+// It can be written as a map of service to condition or as a list
+// of services which then get the service_started condition.
+type DependsOn map[string]string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *DependsOn) UnmarshalJSON(b []byte) error {
+	var list []string
+	if err := json.Unmarshal(b, &list); err == nil {
+		*d = make(DependsOn, len(list))
+		for _, name := range list {
+			(*d)[name] = "service_started"
+		}
+
+		return nil
+	}
+
+	var m map[string]string
+	if err := json.Unmarshal(b, &m); err != nil {
+		return fmt.Errorf("dependsOn must be a list of services or a map of service to condition: %w", err)
+	}
+
+	*d = m
+
+	return nil
+}
+
+// HealthcheckTest is the test of a healthcheck, a plain string is run with CMD-SHELL.
+type HealthcheckTest []string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (t *HealthcheckTest) UnmarshalJSON(b []byte) error {
+	var str string
+	if err := json.Unmarshal(b, &str); err == nil {
+		*t = HealthcheckTest{"CMD-SHELL", str}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(b, &list); err != nil {
+		return fmt.Errorf("healthcheck test must be a string or a list of strings: %w", err)
+	}
+
+	*t = list
+
+	return nil
+}
+
+// Healthcheck is a healthcheck to render into services without one.
+type Healthcheck struct {
+	Test        HealthcheckTest `json:"test"`
+	Interval    string          `json:"interval,omitempty"`
+	Timeout     string          `json:"timeout,omitempty"`
+	Retries     int             `json:"retries,omitempty"`
+	StartPeriod string          `json:"startPeriod,omitempty"`
+}
+
+// ServiceMetadata holds the operator directives of a service's octocompose block.
+type ServiceMetadata struct {
+	DependsOn       DependsOn    `json:"dependsOn,omitempty"`
+	Healthcheck     *Healthcheck `json:"healthcheck,omitempty"`
+	StopGracePeriod string       `json:"stopGracePeriod,omitempty"`
+	PullPolicy      string       `json:"pullPolicy,omitempty"`
+}
+
+// ParseServiceMetadata parses the octocompose block of svc.
+func ParseServiceMetadata(svc map[string]any) (ServiceMetadata, error) {
+	meta := ServiceMetadata{}
+
+	raw, ok := svc["octocompose"]
+	if !ok || raw == nil {
+		return meta, nil
+	}
+
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return meta, fmt.Errorf("while encoding the octocompose block: %w", err)
+	}
+
+	if err := json.Unmarshal(b, &meta); err != nil {
+		return meta, fmt.Errorf("while parsing the octocompose block: %w", err)
+	}
+
+	return meta, nil
+}
+
+// applyMetadata applies the directives of meta to svc.
+func applyMetadata(svc map[string]any, meta ServiceMetadata) error {
+	if err := applyDependsOn(svc, meta.DependsOn); err != nil {
+		return err
+	}
+
+	if err := applyHealthcheck(svc, meta.Healthcheck); err != nil {
+		return err
+	}
+
+	if err := applyStopGracePeriod(svc, meta.StopGracePeriod); err != nil {
+		return err
+	}
+
+	return applyPullPolicy(svc, meta.PullPolicy)
+}
+
+// applyStopGracePeriod renders period into the stop_grace_period of svc, unless svc has one.
+func applyStopGracePeriod(svc map[string]any, period string) error {
+	if period == "" {
+		return nil
+	}
+
+	if _, ok := svc["stop_grace_period"]; ok {
+		return nil
+	}
+
+	if _, err := time.ParseDuration(period); err != nil {
+		return fmt.Errorf("invalid stop grace period '%s': %w", period, err)
+	}
+
+	svc["stop_grace_period"] = period
+
+	return nil
+}
+
+// applyHealthcheck renders hc into the healthcheck of svc, unless svc has one.
+func applyHealthcheck(svc map[string]any, hc *Healthcheck) error {
+	if hc == nil {
+		return nil
+	}
+
+	if _, ok := svc["healthcheck"]; ok {
+		return nil
+	}
+
+	if len(hc.Test) == 0 {
+		return errors.New("octocompose.healthcheck.test is required")
+	}
+
+	healthcheck := map[string]any{}
+
+	test := make([]any, len(hc.Test))
+	for i, item := range hc.Test {
+		test[i] = item
+	}
+
+	healthcheck["test"] = test
+
+	for key, value := range map[string]string{
+		"interval":     hc.Interval,
+		"timeout":      hc.Timeout,
+		"start_period": hc.StartPeriod,
+	} {
+		if value == "" {
+			continue
+		}
+
+		if _, err := time.ParseDuration(value); err != nil {
+			return fmt.Errorf("octocompose.healthcheck has an invalid %s '%s': %w", key, value, err)
+		}
+
+		healthcheck[key] = value
+	}
+
+	if hc.Retries < 0 {
+		return errors.New("octocompose.healthcheck.retries must not be negative")
+	}
+
+	if hc.Retries > 0 {
+		healthcheck["retries"] = hc.Retries
+	}
+
+	svc["healthcheck"] = healthcheck
+
+	return nil
+}
+
+// applyDependsOn adds the wanted dependencies to the compose depends_on of svc,
+// existing depends_on entries are kept.
+func applyDependsOn(svc map[string]any, wanted DependsOn) error {
+	if len(wanted) == 0 {
+		return nil
+	}
+
+	for name, condition := range wanted {
+		if !dependsOnConditions[condition] {
+			return fmt.Errorf("octocompose.dependsOn.%s has an invalid condition '%s'", name, condition)
+		}
+	}
+
+	// Convert the short list syntax so we can add conditions.
+	dependsOn := map[string]any{}
+
+	switch existing := svc["depends_on"].(type) {
+	case []any:
+		for _, item := range existing {
+			dependsOn[fmt.Sprint(item)] = map[string]any{"condition": "service_started"}
+		}
+	case map[string]any:
+		dependsOn = existing
+	}
+
+	for name, condition := range wanted {
+		if _, ok := dependsOn[name]; ok {
+			continue
+		}
+
+		dependsOn[name] = map[string]any{"condition": condition}
+	}
+
+	svc["depends_on"] = dependsOn
+
+	return nil
+}
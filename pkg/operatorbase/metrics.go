@@ -0,0 +1,86 @@
+package operatorbase
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+//nolint:gochecknoglobals
+var (
+	renderedServicesMu sync.Mutex
+	renderedServices   = map[string]int{}
+)
+
+// recordRenderedServices remembers the number of services rendered for project for the metrics.
+func recordRenderedServices(project string, services int) {
+	renderedServicesMu.Lock()
+	defer renderedServicesMu.Unlock()
+
+	renderedServices[project] = services
+}
+
+// RenderedProjects returns the sorted names of the projects rendered by this process.
+func RenderedProjects() []string {
+	renderedServicesMu.Lock()
+	defer renderedServicesMu.Unlock()
+
+	return slices.Sorted(maps.Keys(renderedServices))
+}
+
+// RunMetrics describes a finished command for the metrics file.
+type RunMetrics struct {
+	Command  string
+	ExitCode int
+	Duration time.Duration
+	Finished time.Time
+}
+
+// escapeLabel escapes a Prometheus label value.
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// WriteMetricsFile writes the metrics of a run in the Prometheus textfile format to path,
+// atomically so the node exporter's textfile collector never reads a partial file.
+func WriteMetricsFile(path string, run RunMetrics) error {
+	var b bytes.Buffer
+
+	command := escapeLabel(run.Command)
+
+	fmt.Fprintln(&b, "# HELP operator_docker_run_duration_seconds Duration of the last operator-docker command.")
+	fmt.Fprintln(&b, "# TYPE operator_docker_run_duration_seconds gauge")
+	fmt.Fprintf(&b, "operator_docker_run_duration_seconds{command=\"%s\"} %g\n", command, run.Duration.Seconds())
+
+	fmt.Fprintln(&b, "# HELP operator_docker_run_exit_code Exit code of the last operator-docker command.")
+	fmt.Fprintln(&b, "# TYPE operator_docker_run_exit_code gauge")
+	fmt.Fprintf(&b, "operator_docker_run_exit_code{command=\"%s\"} %d\n", command, run.ExitCode)
+
+	fmt.Fprintln(&b, "# HELP operator_docker_run_timestamp_seconds Time the last operator-docker command finished.")
+	fmt.Fprintln(&b, "# TYPE operator_docker_run_timestamp_seconds gauge")
+	fmt.Fprintf(&b, "operator_docker_run_timestamp_seconds{command=\"%s\"} %d\n", command, run.Finished.Unix())
+
+	renderedServicesMu.Lock()
+	services := maps.Clone(renderedServices)
+	renderedServicesMu.Unlock()
+
+	if len(services) > 0 {
+		fmt.Fprintln(&b, "# HELP operator_docker_services Number of services rendered for the project.")
+		fmt.Fprintln(&b, "# TYPE operator_docker_services gauge")
+
+		for _, project := range slices.Sorted(maps.Keys(services)) {
+			fmt.Fprintf(&b, "operator_docker_services{command=\"%s\",project=\"%s\"} %d\n", command, escapeLabel(project), services[project])
+		}
+	}
+
+	// The node exporter usually runs as another user.
+	if err := writeFileAtomic(path, b.Bytes(), 0644); err != nil { //nolint:gosec
+		return fmt.Errorf("while writing the metrics file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package operatorbase
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/urfave/cli/v3"
+)
+
+// mtuDriverOpt is the driver option docker's bridge and overlay drivers take the MTU from.
+const mtuDriverOpt = "com.docker.network.driver.mtu"
+
+// defaultNetworkOptions returns the driver and MTU for the default network from the
+// network-driver and network-mtu flags, else from octoctl.networkDriver and octoctl.networkMTU.
+func defaultNetworkOptions(cmd *cli.Command, data map[string]any) (string, int, error) {
+	driver := cmd.String("network-driver")
+	if driver == "" {
+		var err error
+
+		driver, err = OctoctlString(data, "networkDriver")
+		if err != nil {
+			return "", 0, err
+		}
+	}
+
+	mtu := int(cmd.Int("network-mtu"))
+	if mtu == 0 {
+		switch v := octoctlSection(data)["networkMTU"].(type) {
+		case nil:
+		case int:
+			mtu = v
+		case int64:
+			mtu = int(v)
+		case uint64:
+			mtu = int(v) //nolint:gosec
+		case float64:
+			mtu = int(v)
+		default:
+			return "", 0, fmt.Errorf("octoctl.networkMTU must be a number, got %T", v)
+		}
+	}
+
+	if mtu < 0 {
+		return "", 0, fmt.Errorf("invalid network MTU %d", mtu)
+	}
+
+	return driver, mtu, nil
+}
+
+// applyDefaultNetwork defines the default network of the project with driver and mtu,
+// unless the config defines the default network itself.
+func applyDefaultNetwork(data map[string]any, driver string, mtu int) error {
+	if driver == "" && mtu == 0 {
+		return nil
+	}
+
+	networks := map[string]any{}
+
+	switch existing := data["networks"].(type) {
+	case nil:
+	case map[string]any:
+		networks = existing
+	default:
+		return fmt.Errorf("networks must be a map, got %T", existing)
+	}
+
+	if _, ok := networks["default"]; ok {
+		return nil
+	}
+
+	network := map[string]any{}
+
+	if driver != "" {
+		network["driver"] = driver
+	}
+
+	if mtu > 0 {
+		network["driver_opts"] = map[string]any{mtuDriverOpt: strconv.Itoa(mtu)}
+	}
+
+	networks["default"] = network
+	data["networks"] = networks
+
+	return nil
+}
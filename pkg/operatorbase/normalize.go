@@ -0,0 +1,38 @@
+package operatorbase
+
+import (
+	"fmt"
+)
+
+// normalize returns a deep copy of a decoded config value with all maps as map[string]any.
+//
+// YAML decoders resolve anchors by reusing the anchored value, so two services
+// referencing the same anchor (or merging it with <<) may share maps. Copying
+// makes sure changing one service in PrepareConfig never changes another.
+func normalize(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, item := range v {
+			result[key] = normalize(item)
+		}
+
+		return result
+	case map[any]any:
+		result := make(map[string]any, len(v))
+		for key, item := range v {
+			result[fmt.Sprint(key)] = normalize(item)
+		}
+
+		return result
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = normalize(item)
+		}
+
+		return result
+	default:
+		return v
+	}
+}
@@ -0,0 +1,97 @@
+package operatorbase
+
+import (
+	"testing"
+
+	_ "github.com/go-orb/plugins/codecs/yaml"
+)
+
+// assertStringMaps fails when value contains a map which isn't a map[string]any.
+func assertStringMaps(t *testing.T, path string, value any) {
+	t.Helper()
+
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			assertStringMaps(t, path+"."+key, item)
+		}
+	case []any:
+		for _, item := range v {
+			assertStringMaps(t, path+"[]", item)
+		}
+	case map[any]any:
+		t.Errorf("%s is a map[any]any", path)
+	}
+}
+
+func TestNormalizeAnchors(t *testing.T) {
+	data, err := ReadConfigDir("testdata/anchors", 1<<20)
+	if err != nil {
+		t.Fatalf("ReadConfigDir() error = %v", err)
+	}
+
+	assertStringMaps(t, "config", data)
+
+	services, ok := data["services"].(map[string]any)
+	if !ok {
+		t.Fatalf("services is a %T", data["services"])
+	}
+
+	web, ok := services["web"].(map[string]any)
+	if !ok {
+		t.Fatalf("services.web is a %T", services["web"])
+	}
+
+	if web["restart"] != "unless-stopped" || web["image"] != "nginx" {
+		t.Fatalf("services.web = %v, want the merged defaults", web)
+	}
+
+	logging, ok := web["logging"].(map[string]any)
+	if !ok {
+		t.Fatalf("services.web.logging is a %T", web["logging"])
+	}
+
+	options, ok := logging["options"].(map[string]any)
+	if !ok || options["max-size"] != "10m" {
+		t.Fatalf("services.web.logging.options = %v", logging["options"])
+	}
+
+	// Services sharing an anchor must not share their maps.
+	apiLabels := services["api"].(map[string]any)["labels"].(map[string]any)       //nolint:forcetypeassert
+	workerLabels := services["worker"].(map[string]any)["labels"].(map[string]any) //nolint:forcetypeassert
+
+	apiLabels["team"] = "changed"
+
+	if workerLabels["team"] != "platform" {
+		t.Errorf("changing services.api.labels changed services.worker.labels")
+	}
+
+	options["max-size"] = "1m"
+
+	apiOptions := services["api"].(map[string]any)["logging"].(map[string]any)["options"].(map[string]any) //nolint:forcetypeassert
+	if apiOptions["max-size"] != "10m" {
+		t.Errorf("changing services.web.logging changed services.api.logging")
+	}
+}
+
+func TestNormalizeAnyKeys(t *testing.T) {
+	shared := map[any]any{"key": "value"}
+
+	value := normalize(map[string]any{
+		"a": shared,
+		"b": []any{shared, map[any]any{1: "one"}},
+	})
+
+	assertStringMaps(t, "value", value)
+
+	result := value.(map[string]any)                //nolint:forcetypeassert
+	result["a"].(map[string]any)["key"] = "changed" //nolint:forcetypeassert
+
+	if shared["key"] != "value" {
+		t.Errorf("normalize() didn't copy the shared map")
+	}
+
+	if got := result["b"].([]any)[1].(map[string]any)["1"]; got != "one" { //nolint:forcetypeassert
+		t.Errorf("normalize() key 1 = %v, want one", got)
+	}
+}
@@ -0,0 +1,156 @@
+package operatorbase
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+)
+
+// octoctlSection returns the octoctl section of the config, or nil.
+func octoctlSection(data map[string]any) map[string]any {
+	octoctl, _ := data["octoctl"].(map[string]any) //nolint:errcheck
+	return octoctl
+}
+
+// OctoctlStringList returns the string list octoctl.<key> of the config.
+func OctoctlStringList(data map[string]any, key string) ([]string, error) {
+	raw, ok := octoctlSection(data)[key]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+
+	list, ok := raw.([]any)
+	if !ok {
+		return nil, fmt.Errorf("octoctl.%s must be a list of strings", key)
+	}
+
+	result := make([]string, 0, len(list))
+
+	for i, item := range list {
+		str, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("octoctl.%s[%d] must be a string", key, i)
+		}
+
+		result = append(result, str)
+	}
+
+	return result, nil
+}
+
+// OctoctlString returns the string octoctl.<key> of the config, or "" when it's not set.
+func OctoctlString(data map[string]any, key string) (string, error) {
+	raw, ok := octoctlSection(data)[key]
+	if !ok || raw == nil {
+		return "", nil
+	}
+
+	str, ok := raw.(string)
+	if !ok {
+		return "", fmt.Errorf("octoctl.%s must be a string", key)
+	}
+
+	return str, nil
+}
+
+// OctoctlStringMap returns the string to string map octoctl.<key> of the config.
+func OctoctlStringMap(data map[string]any, key string) (map[string]string, error) {
+	raw, ok := octoctlSection(data)[key]
+	if !ok || raw == nil {
+		return nil, nil //nolint:nilnil
+	}
+
+	rawMap, ok := raw.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("octoctl.%s must be a map of strings", key)
+	}
+
+	result := make(map[string]string, len(rawMap))
+
+	for name, value := range rawMap {
+		str, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("octoctl.%s.%s must be a string", key, name)
+		}
+
+		result[name] = str
+	}
+
+	return result, nil
+}
+
+// CommandOverride returns the compose command configured for the command name
+// in octoctl.commands, or nil when there's none.
+func CommandOverride(data map[string]any, name string) ([]string, error) {
+	raw, ok := octoctlSection(data)["commands"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+
+	commands, ok := raw.(map[string]any)
+	if !ok {
+		return nil, errors.New("octoctl.commands must be a map of command name to command")
+	}
+
+	entry, ok := commands[name]
+	if !ok {
+		return nil, nil
+	}
+
+	list, ok := entry.([]any)
+	if !ok || len(list) == 0 {
+		return nil, fmt.Errorf("octoctl.commands.%s must be a non-empty list of strings", name)
+	}
+
+	command := make([]string, 0, len(list))
+
+	for i, item := range list {
+		str, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("octoctl.commands.%s[%d] must be a string", name, i)
+		}
+
+		command = append(command, str)
+	}
+
+	return command, nil
+}
+
+// resolveComposeCommand returns the compose command to run cmd with: --compose-command,
+// else octoctl.commands.<name>, else octoctl.composeCommand, else defaultCommand.
+// It returns an error if the command isn't on PATH.
+func resolveComposeCommand(cmd *cli.Command, data map[string]any, defaultCommand []string) ([]string, error) {
+	composeCommand := strings.Fields(cmd.String("compose-command"))
+
+	if len(composeCommand) == 0 {
+		override, err := CommandOverride(data, cmd.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		composeCommand = override
+	}
+
+	if len(composeCommand) == 0 {
+		configured, err := OctoctlStringList(data, "composeCommand")
+		if err != nil {
+			return nil, err
+		}
+
+		composeCommand = configured
+	}
+
+	if len(composeCommand) == 0 {
+		composeCommand = defaultCommand
+	}
+
+	if _, err := exec.LookPath(composeCommand[0]); err != nil {
+		return nil, fmt.Errorf("%s not found; is it installed and on PATH? (command: %s)",
+			composeCommand[0], strings.Join(composeCommand, " "))
+	}
+
+	return composeCommand, nil
+}
@@ -6,9 +6,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-orb/go-orb/codecs"
 	"github.com/go-orb/go-orb/config"
@@ -21,14 +25,94 @@ import (
 type ComposeFilePathKey struct{}
 type ComposeCommandKey struct{}
 type LoggerKey struct{}
+type RepoKey struct{}
+type LineBufferedKey struct{}
+type ComposeArgsKey struct{}
+type FileModeKey struct{}
+type EnvFilesKey struct{}
+type StdoutKey struct{}
+type StderrKey struct{}
+type ProjectsKey struct{}
+type ProjectKey struct{}
+
+// extConfigFormat returns the config format of a file extension, or "" when it's unknown.
+func extConfigFormat(ext string) string {
+	switch strings.ToLower(ext) {
+	case ".toml":
+		return "toml"
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".json":
+		return "json"
+	default:
+		return ""
+	}
+}
+
+// configMime returns the mime type of the config codec, either from
+// the config-format flag or from the file extension, a fetched config
+// falls back to the content type of the response.
+func configMime(cmd *cli.Command, contentType string) (string, error) {
+	format := cmd.String("config-format")
+	if format == "" && IsConfigURL(cmd.String("config")) {
+		var err error
+
+		format, err = urlConfigFormat(cmd.String("config"), contentType)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if format == "" {
+		format = extConfigFormat(filepath.Ext(cmd.String("config")))
+	}
 
-// ReadConfig reads the config from stdin
-func ReadConfig(logger log.Logger, cmd *cli.Command) (map[string]any, error) {
+	switch strings.ToLower(format) {
+	case "json", "":
+		return codecs.MimeJSON, nil
+	case "yaml":
+		return codecs.MimeYAML, nil
+	case "toml":
+		return codecs.MimeTOML, nil
+	default:
+		return "", fmt.Errorf("unknown config format '%s'", format)
+	}
+}
+
+// openConfig opens the config file, "-" stands for stdin.
+func openConfig(configFile string) (io.ReadCloser, error) {
+	if configFile == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+
+	return os.Open(configFile) //nolint:gosec
+}
+
+// readConfigBytes reads the config from an http(s) URL, the config file or stdin,
+// it returns the content type of the response for fetched configs.
+func readConfigBytes(ctx context.Context, logger log.Logger, cmd *cli.Command) ([]byte, string, error) {
 	configFile := cmd.String("config")
-	fp, err := os.Open(configFile)
+
+	maxSize := cmd.Int("max-config-size")
+	if maxSize <= 0 {
+		logger.Error("Invalid max config size", "value", maxSize)
+		return nil, "", errors.New("--max-config-size must be positive")
+	}
+
+	if IsConfigURL(configFile) {
+		b, contentType, err := fetchConfig(ctx, configFile, cmd.String("config-token"), cmd.Duration("config-timeout"), maxSize)
+		if err != nil {
+			logger.Error("Error while fetching the config", "url", configFile, "error", err)
+			return nil, "", err
+		}
+
+		return b, contentType, nil
+	}
+
+	fp, err := openConfig(configFile)
 	if err != nil {
 		logger.Error("Error while opening config file", "error", err)
-		return nil, fmt.Errorf("while opening config file: %w", err)
+		return nil, "", fmt.Errorf("while opening config file: %w", err)
 	}
 	defer func() {
 		if err := fp.Close(); err != nil {
@@ -36,13 +120,64 @@ func ReadConfig(logger log.Logger, cmd *cli.Command) (map[string]any, error) {
 		}
 	}()
 
-	b, err := io.ReadAll(fp)
+	b, err := readLimited(fp, maxSize)
 	if err != nil {
 		logger.Error("Error while reading config file", "error", err)
-		return nil, fmt.Errorf("while reading config file: %w", err)
+		return nil, "", fmt.Errorf("while reading config file: %w", err)
+	}
+
+	return b, "", nil
+}
+
+// readLimited reads r to the end, failing when it holds more than maxSize bytes.
+func readLimited(r io.Reader, maxSize int64) ([]byte, error) {
+	b, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(b)) > maxSize {
+		return nil, fmt.Errorf("the config exceeds the maximum size of %d bytes, raise it with --max-config-size", maxSize)
+	}
+
+	return b, nil
+}
+
+// ReadConfig reads the config from an http(s) URL, the config file, from stdin when it's "-"
+// or merges it from the files of the config directory.
+func ReadConfig(ctx context.Context, logger log.Logger, cmd *cli.Command) (map[string]any, error) {
+	if dir := cmd.String("config-dir"); dir != "" {
+		if cmd.String("config") != "" {
+			logger.Error("Both a config file and a config directory given")
+			return nil, errors.New("--config and --config-dir can't be combined")
+		}
+
+		data, err := ReadConfigDir(dir, cmd.Int("max-config-size"))
+		if err != nil {
+			logger.Error("Error while reading the config directory", "error", err)
+			return nil, err
+		}
+
+		return data, nil
+	}
+
+	if cmd.String("config") == "" {
+		logger.Error("No config file given")
+		return nil, errors.New("the config file is required, set it with --config or --config-dir")
+	}
+
+	b, contentType, err := readConfigBytes(ctx, logger, cmd)
+	if err != nil {
+		return nil, err
 	}
 
-	codec, err := codecs.GetMime(codecs.MimeJSON)
+	mime, err := configMime(cmd, contentType)
+	if err != nil {
+		logger.Error("Error while selecting the config format", "error", err)
+		return nil, err
+	}
+
+	codec, err := codecs.GetMime(mime)
 	if err != nil {
 		logger.Error("Error while getting codec", "error", err)
 		return nil, fmt.Errorf("while getting codec: %w", err)
@@ -54,15 +189,93 @@ func ReadConfig(logger log.Logger, cmd *cli.Command) (map[string]any, error) {
 		return nil, fmt.Errorf("while unmarshalling: %w", err)
 	}
 
-	return data, nil
+	return normalize(data).(map[string]any), nil
 }
 
-// PrepareConfig prepares the config
-func PrepareConfig(logger log.Logger, data map[string]any) (map[string]any, error) {
+// loggingConfig returns the logging section from the log-driver and log-opt flags,
+// or nil when no log driver has been given.
+func loggingConfig(cmd *cli.Command) (map[string]any, error) {
+	driver := cmd.String("log-driver")
+	if driver == "" {
+		return nil, nil //nolint:nilnil
+	}
+
+	options := map[string]any{}
+
+	for _, opt := range cmd.StringSlice("log-opt") {
+		key, value, ok := strings.Cut(opt, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid log option '%s', expected key=value", opt)
+		}
+
+		options[key] = value
+	}
+
+	logging := map[string]any{"driver": driver}
+	if len(options) > 0 {
+		logging["options"] = options
+	}
+
+	return logging, nil
+}
+
+// LoadRepo parses the repos section of the config.
+func LoadRepo(data map[string]any) (octoconfig.Repo, error) {
 	repo := octoconfig.Repo{}
 	if err := config.Parse(nil, "repos", data, &repo); err != nil {
-		logger.Error("Error while parsing config", "error", err)
-		return nil, fmt.Errorf("while parsing config: %w", err)
+		return repo, fmt.Errorf("while parsing config: %w", err)
+	}
+
+	return repo, nil
+}
+
+// RepoConfig returns the parsed repos section stored by BeforeConfig.
+func RepoConfig(ctx context.Context) octoconfig.Repo {
+	return ctx.Value(RepoKey{}).(octoconfig.Repo)
+}
+
+// PrepareConfig prepares the config
+func PrepareConfig(logger log.Logger, cmd *cli.Command, data map[string]any, repo octoconfig.Repo) (map[string]any, error) {
+	logging, err := loggingConfig(cmd)
+	if err != nil {
+		logger.Error("Error while parsing the logging flags", "error", err)
+		return nil, err
+	}
+
+	variables, err := Variables(data)
+	if err != nil {
+		logger.Error("Error while reading the variables", "error", err)
+		return nil, err
+	}
+
+	composeEnv, err := OctoctlStringMap(data, "composeEnv")
+	if err != nil {
+		logger.Error("Error while reading the compose env", "error", err)
+		return nil, err
+	}
+
+	extraHosts, err := OctoctlStringList(data, "extraHosts")
+	if err != nil {
+		logger.Error("Error while reading the extra hosts", "error", err)
+		return nil, err
+	}
+
+	extraHosts = append(extraHosts, cmd.StringSlice("add-host")...)
+
+	commandPrecedence := cmd.String("command-precedence")
+	if commandPrecedence == "" {
+		commandPrecedence = "repo"
+	}
+
+	if commandPrecedence != "repo" && commandPrecedence != "service" {
+		logger.Error("Invalid command precedence", "value", commandPrecedence)
+		return nil, fmt.Errorf("invalid command precedence '%s', expected repo or service", commandPrecedence)
+	}
+
+	networkDriver, networkMTU, err := defaultNetworkOptions(cmd, data)
+	if err != nil {
+		logger.Error("Error while reading the network options", "error", err)
+		return nil, err
 	}
 
 	delete(data, "configs")
@@ -75,8 +288,29 @@ func PrepareConfig(logger log.Logger, data map[string]any) (map[string]any, erro
 		return nil, errors.New("services not found")
 	}
 
+	var selected []string
+
+	if path := cmd.String("services-from-file"); path != "" {
+		selected, err = ReadServiceList(path)
+		if err != nil {
+			logger.Error("Error while reading the service list", "error", err)
+			return nil, err
+		}
+
+		for _, name := range selected {
+			if _, ok := services[name]; !ok {
+				logger.Error("Listed service not found", "service", name)
+				return nil, &Error{Stage: StagePrepare, Service: name, Err: errors.New("listed service not found in the config")}
+			}
+		}
+	}
+
 	for name := range services {
-		svc := services[name].(map[string]any)
+		svc, ok := services[name].(map[string]any)
+		if !ok {
+			logger.Error("service is not a map", "service", name)
+			return nil, &Error{Stage: StagePrepare, Service: name, Err: errors.New("service is not a map")}
+		}
 
 		// Remove disabled services
 		if svc["enabled"] != nil && !svc["enabled"].(bool) {
@@ -84,16 +318,55 @@ func PrepareConfig(logger log.Logger, data map[string]any) (map[string]any, erro
 			continue
 		}
 
+		meta, err := ParseServiceMetadata(svc)
+		if err != nil {
+			logger.Error("Error while parsing the service metadata", "service", name, "error", err)
+			return nil, &Error{Stage: StagePrepare, Service: name, Err: err}
+		}
+
+		if meta.StopGracePeriod == "" {
+			meta.StopGracePeriod = cmd.String("stop-grace-period")
+		}
+
+		if err := applyMetadata(svc, meta); err != nil {
+			logger.Error("Error while applying the service metadata", "service", name, "error", err)
+			return nil, &Error{Stage: StagePrepare, Service: name, Err: err}
+		}
+
 		delete(svc, "octocompose")
 
+		if _, ok := svc["logging"]; !ok && logging != nil {
+			svc["logging"] = logging
+		}
+
+		if cmd.Bool("translate-replicas") {
+			replicas, ok, err := translateReplicas(svc)
+			if err != nil {
+				logger.Error("Error while translating the replicas", "service", name, "error", err)
+				return nil, &Error{Stage: StagePrepare, Service: name, Err: err}
+			}
+
+			if ok {
+				logger.Info("Translated deploy.replicas to scale", "service", name, "replicas", replicas)
+			}
+		}
+
+		if err := addExtraHosts(svc, extraHosts); err != nil {
+			logger.Error("Error while adding the extra hosts", "service", name, "error", err)
+			return nil, &Error{Stage: StagePrepare, Service: name, Err: err}
+		}
+
 		if svcRepo, ok := repo.Services[name]; ok && svcRepo.Docker != nil {
 			svc["image"] = svcRepo.Docker.Registry + "/" + svcRepo.Docker.Image + ":" + svcRepo.Docker.Tag
 
-			if svcRepo.Docker.Command != nil {
+			// With service precedence the repo only fills in a missing command/entrypoint.
+			_, hasCommand := svc["command"]
+			if svcRepo.Docker.Command != nil && (commandPrecedence == "repo" || !hasCommand) {
 				svc["command"] = svcRepo.Docker.Command
 			}
 
-			if svcRepo.Docker.Entrypoint != "" {
+			_, hasEntrypoint := svc["entrypoint"]
+			if svcRepo.Docker.Entrypoint != "" && (commandPrecedence == "repo" || !hasEntrypoint) {
 				svc["entrypoint"] = svcRepo.Docker.Entrypoint
 			}
 		} else {
@@ -101,11 +374,146 @@ func PrepareConfig(logger log.Logger, data map[string]any) (map[string]any, erro
 		}
 	}
 
+	if selected != nil {
+		selectServices(services, selected)
+	}
+
+	if cmd.Bool("env-substitution-strict") {
+		envFile, err := discoverEnvFile(cmd.String("config"), !cmd.Bool("no-env-file-discovery"))
+		if err != nil {
+			return nil, err
+		}
+
+		var envFiles []string
+		if envFile != "" {
+			envFiles = append(envFiles, envFile)
+		}
+
+		// octoctl.composeEnv and start --set reach compose through its environment.
+		setValues, err := ParseKeyValues(cmd.StringSlice("set"))
+		if err != nil {
+			return nil, err
+		}
+
+		defined := maps.Clone(variables)
+		if defined == nil {
+			defined = map[string]string{}
+		}
+
+		maps.Copy(defined, composeEnv)
+		maps.Copy(defined, setValues)
+
+		if err := CheckInterpolation(services, defined, envFiles); err != nil {
+			logger.Error("Undefined variables", "error", err)
+			return nil, err
+		}
+	}
+
+	if err := prefixVolumes(data, services, cmd.String("volume-prefix")); err != nil {
+		logger.Error("Error while prefixing the volumes", "error", err)
+		return nil, err
+	}
+
+	if err := applyDefaultNetwork(data, networkDriver, networkMTU); err != nil {
+		logger.Error("Error while configuring the default network", "error", err)
+		return nil, err
+	}
+
 	return data, nil
 }
 
+// FileMode returns the mode of the files written next to the compose file, --file-mode.
+func FileMode(ctx context.Context) os.FileMode {
+	if fileMode, ok := ctx.Value(FileModeKey{}).(os.FileMode); ok {
+		return fileMode
+	}
+
+	return 0600
+}
+
+// ParseFileMode parses an octal file mode like "0640".
+func ParseFileMode(s string) (os.FileMode, error) {
+	mode, err := strconv.ParseUint(s, 8, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid file mode '%s': %w", s, err)
+	}
+
+	if mode&^uint64(os.ModePerm) != 0 {
+		return 0, fmt.Errorf("invalid file mode '%s': only permission bits are allowed", s)
+	}
+
+	if mode&0400 == 0 {
+		return 0, fmt.Errorf("invalid file mode '%s': the owner must be able to read the file", s)
+	}
+
+	return os.FileMode(mode), nil
+}
+
+// dirMode returns the directory mode matching a file mode,
+// every class that can read the file may also traverse the directory.
+func dirMode(fileMode os.FileMode) os.FileMode {
+	mode := fileMode | 0700
+	if fileMode&0040 != 0 {
+		mode |= 0010
+	}
+
+	if fileMode&0004 != 0 {
+		mode |= 0001
+	}
+
+	return mode
+}
+
+// composeFileMode returns the mode of the compose file, the files written next to it
+// (deploy hash, deployed copy, history) get the same mode as --file-mode.
+func composeFileMode(composeFilePath string) os.FileMode {
+	info, err := os.Stat(composeFilePath)
+	if err != nil {
+		return 0600
+	}
+
+	return info.Mode().Perm()
+}
+
+// writeComposeSibling writes a file next to composeFilePath with the mode of the compose file.
+func writeComposeSibling(composeFilePath, path string, b []byte) error {
+	fileMode := composeFileMode(composeFilePath)
+
+	if err := os.WriteFile(path, b, fileMode); err != nil {
+		return err
+	}
+
+	// WriteFile keeps the mode of an existing file and is subject to the umask.
+	return os.Chmod(path, fileMode)
+}
+
+// composeFilePathFor returns the path of the compose file of projectID.
+// It's the compose-file flag if given, a file in a temporary directory with the
+// no-cache-write flag, otherwise a file in the user cache directory.
+func composeFilePathFor(cmd *cli.Command, projectID string) (string, error) {
+	if path := cmd.String("compose-file"); path != "" {
+		return filepath.Abs(path)
+	}
+
+	if cmd.Bool("no-cache-write") {
+		dir, err := tempComposeDir(projectID)
+		if err != nil {
+			return "", err
+		}
+
+		return filepath.Join(dir, "compose.yaml"), nil
+	}
+
+	root, err := CacheRoot()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(root, projectID, "compose.yaml"), nil
+}
+
 // WriteConfig writes the config to a file
-func WriteConfig(logger log.Logger, data map[string]any, projectID string) (string, error) {
+func WriteConfig(logger log.Logger, cmd *cli.Command, data map[string]any, projectID string, fileMode os.FileMode) (string, error) {
 	codec, err := codecs.GetMime(codecs.MimeYAML)
 	if err != nil {
 		logger.Error("Error while getting codec", "error", err)
@@ -118,18 +526,25 @@ func WriteConfig(logger log.Logger, data map[string]any, projectID string) (stri
 		return "", fmt.Errorf("while marshalling: %w", err)
 	}
 
-	userCacheDir, err := os.UserCacheDir()
+	composeFilePath, err := composeFilePathFor(cmd, projectID)
 	if err != nil {
-		logger.Error("Error while getting cache directory", "error", err)
-		return "", fmt.Errorf("while getting cache directory: %w", err)
+		logger.Error("Error while getting the compose file path", "error", err)
+		return "", err
 	}
 
-	composeFilePath := filepath.Join(userCacheDir, "octocompose", projectID, "compose.yaml")
-	if err := os.MkdirAll(filepath.Dir(composeFilePath), 0700); err != nil {
+	if err := os.MkdirAll(filepath.Dir(composeFilePath), dirMode(fileMode)); err != nil {
 		logger.Error("Error while creating the cache directory", "error", err)
 		return "", fmt.Errorf("while creating the cache directory: %w", err)
 	}
 
+	// MkdirAll leaves an existing directory alone, a given --compose-file may live anywhere.
+	if cmd.String("compose-file") == "" {
+		if err := os.Chmod(filepath.Dir(composeFilePath), dirMode(fileMode)); err != nil {
+			logger.Error("Error while changing the directory mode", "error", err)
+			return "", fmt.Errorf("while changing the directory mode: %w", err)
+		}
+	}
+
 	// Remove existing file.
 	if _, err := os.Stat(composeFilePath); err == nil {
 		if err := os.Remove(composeFilePath); err != nil {
@@ -137,46 +552,213 @@ func WriteConfig(logger log.Logger, data map[string]any, projectID string) (stri
 		}
 	}
 
-	if err := os.WriteFile(composeFilePath, b, 0600); err != nil {
+	if err := os.WriteFile(composeFilePath, b, fileMode); err != nil {
 		logger.Error("Error while writing file", "error", err)
 		return "", fmt.Errorf("while writing file: %w", err)
 	}
 
+	// WriteFile is subject to the umask, enforce the requested mode.
+	if err := os.Chmod(composeFilePath, fileMode); err != nil {
+		logger.Error("Error while changing the file mode", "error", err)
+		return "", fmt.Errorf("while changing the file mode: %w", err)
+	}
+
+	logger.Trace("Wrote compose file", "path", composeFilePath, "content", Redact(data, redactPatterns(cmd)))
+
 	return composeFilePath, nil
 }
 
+// configBaseDir returns the directory relative paths of the config are resolved against:
+// the config directory, else the directory of the config file, else "" for the
+// working directory when the config is read from stdin or a URL.
+func configBaseDir(cmd *cli.Command) string {
+	if dir := cmd.String("config-dir"); dir != "" {
+		return dir
+	}
+
+	if configFile := cmd.String("config"); configFile != "" && configFile != "-" && !IsConfigURL(configFile) {
+		return filepath.Dir(configFile)
+	}
+
+	return ""
+}
+
+// resolveConfigPath returns the absolute path of a path from the config,
+// relative paths are resolved against configBaseDir.
+func resolveConfigPath(cmd *cli.Command, path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(configBaseDir(cmd), path)
+	}
+
+	return filepath.Abs(path)
+}
+
+// projectDirectory returns the --project-directory flag, or else octoctl.workingDir
+// of the config resolved against the directory of the config.
+func projectDirectory(cmd *cli.Command, data map[string]any) (string, error) {
+	if dir := cmd.String("project-directory"); dir != "" {
+		return dir, nil
+	}
+
+	dir, err := OctoctlString(data, "workingDir")
+	if err != nil || dir == "" {
+		return "", err
+	}
+
+	return resolveConfigPath(cmd, dir)
+}
+
 // BeforeConfig is a function that is called before the command is executed.
 func BeforeConfig(composeCommand []string) func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
 	return func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+		// Overrides from the config must not change the default of later invocations.
+		composeCommand := composeCommand
+
 		logger, err := log.New(log.WithLevel(cmd.String("log-level")))
 		if err != nil {
 			return ctx, err
 		}
 
 		ctx = context.WithValue(ctx, LoggerKey{}, logger)
+		ctx = context.WithValue(ctx, LineBufferedKey{}, cmd.Bool("line-buffered"))
+		ctx = context.WithValue(ctx, RedactKey{}, redactPatterns(cmd))
+
+		if limit := cmd.Int("parallel-pull-limit"); limit != 0 {
+			if limit < 0 {
+				logger.Error("Invalid parallel pull limit", "value", limit)
+				return ctx, stageError(StagePrepare, errors.New("--parallel-pull-limit must be a positive integer"))
+			}
+
+			ctx = WithEnv(ctx, map[string]string{"COMPOSE_PARALLEL_LIMIT": strconv.FormatInt(limit, 10)})
+		}
+
+		if err := checkReadOnly(logger, cmd); err != nil {
+			logger.Error("Refusing to run in read-only mode", "command", cmd.Name)
+			return ctx, stageError(StageReadOnly, err)
+		}
 
-		configData, err := ReadConfig(logger, cmd)
+		fileMode, err := ParseFileMode(cmd.String("file-mode"))
+		if err != nil {
+			logger.Error("Error while parsing the file mode", "error", err)
+			return ctx, stageError(StageWrite, err)
+		}
+
+		ctx = context.WithValue(ctx, FileModeKey{}, fileMode)
+
+		configData, err := ReadConfig(ctx, logger, cmd)
 		if err != nil {
 			logger.Error("Error while reading config", "error", err)
-			os.Exit(1)
+			return ctx, stageError(StageReadConfig, err)
+		}
+
+		defaults, err := ParseCommandDefaults(configData)
+		if err != nil {
+			logger.Error("Error while reading the command defaults", "error", err)
+			return ctx, stageError(StagePrepare, err)
 		}
 
-		projectID := configData["name"].(string)
+		if err := applyCommandDefaults(cmd, defaults); err != nil {
+			logger.Error("Error while applying the command defaults", "error", err)
+			return ctx, stageError(StagePrepare, err)
+		}
 
-		configData, err = PrepareConfig(logger, configData)
+		composeCommand, err = resolveComposeCommand(cmd, configData, composeCommand)
 		if err != nil {
-			logger.Error("Error while reading and preparing config", "error", err)
-			os.Exit(1)
+			logger.Error("Error while resolving the compose command", "error", err)
+			return ctx, stageError(StageRun, err)
 		}
 
-		composeFilePath, err := WriteConfig(logger, configData, projectID)
+		logger.Debug("Using compose command", "command", composeCommand)
+
+		sudo, err := sudoCommand(cmd, configData)
 		if err != nil {
-			logger.Error("Error while writing config", "error", err)
-			os.Exit(1)
+			logger.Error("Error while reading the privilege escalation command", "error", err)
+			return ctx, stageError(StagePrepare, err)
 		}
 
-		ctx = context.WithValue(ctx, ComposeFilePathKey{}, composeFilePath)
+		ctx = context.WithValue(ctx, SudoCommandKey{}, sudo)
+
+		composeEnv, err := OctoctlStringMap(configData, "composeEnv")
+		if err != nil {
+			logger.Error("Error while reading the compose environment", "error", err)
+			return ctx, stageError(StagePrepare, err)
+		}
+
+		// Environment set by flags like --parallel-pull-limit wins over the config.
+		ctx = withEnvDefaults(ctx, composeEnv)
+
+		ctx, err = withBuildKit(ctx, cmd, configData)
+		if err != nil {
+			logger.Error("Error while configuring BuildKit", "error", err)
+			return ctx, stageError(StagePrepare, err)
+		}
+
+		composeArgs, err := OctoctlStringList(configData, "composeArgs")
+		if err != nil {
+			logger.Error("Error while reading the compose args", "error", err)
+			return ctx, stageError(StagePrepare, err)
+		}
+
+		if cmd.Bool("compatibility") && !slices.Contains(composeArgs, "--compatibility") {
+			composeArgs = append(composeArgs, "--compatibility")
+		}
+
+		profiles, err := OctoctlStringList(configData, "profiles")
+		if err != nil {
+			logger.Error("Error while reading the profiles", "error", err)
+			return ctx, stageError(StagePrepare, err)
+		}
+
+		for _, profile := range append(profiles, cmd.StringSlice("profile")...) {
+			composeArgs = append(composeArgs, "--profile", profile)
+		}
+
+		projectDirectory, err := projectDirectory(cmd, configData)
+		if err != nil {
+			logger.Error("Error while reading the working directory", "error", err)
+			return ctx, stageError(StagePrepare, err)
+		}
+
+		if projectDirectory != "" {
+			composeArgs = append(composeArgs, "--project-directory", projectDirectory)
+		}
+
+		ctx = context.WithValue(ctx, ComposeArgsKey{}, composeArgs)
+
+		projectConfigs, err := SplitProjects(configData)
+		if err != nil {
+			logger.Error("Error while reading the projects", "error", err)
+			return ctx, stageError(StagePrepare, err)
+		}
+
+		if len(projectConfigs) > 1 && cmd.String("compose-file") != "" && cmd.String("project") == "" {
+			logger.Error("A compose file path requires a single project")
+			return ctx, stageError(StagePrepare, errors.New("--compose-file requires a single project, select one with --project"))
+		}
+
+		projects := make([]Project, 0, len(projectConfigs))
+
+		for _, projectConfig := range projectConfigs {
+			if filter := cmd.String("project"); filter != "" && projectConfig["name"] != composeProjectName(filter) {
+				continue
+			}
+
+			project, err := renderProject(logger, cmd, projectConfig, fileMode, composeCommand)
+			if err != nil {
+				return ctx, err
+			}
+
+			projects = append(projects, project)
+		}
+
+		if len(projects) == 0 {
+			logger.Error("Project not found", "project", cmd.String("project"))
+			return ctx, stageError(StagePrepare, fmt.Errorf("project '%s' not found", cmd.String("project")))
+		}
+
+		ctx = context.WithValue(ctx, ProjectsKey{}, projects)
 		ctx = context.WithValue(ctx, ComposeCommandKey{}, composeCommand)
+		ctx = WithProject(ctx, projects[0])
 
 		return ctx, nil
 	}
@@ -185,25 +767,117 @@ func BeforeConfig(composeCommand []string) func(ctx context.Context, cmd *cli.Co
 // RunCmd is a function that is called to run a command.
 func RunCmd(ctx context.Context, args []string) error {
 	logger := ctx.Value(LoggerKey{}).(log.Logger)
+
+	args = withSudo(ctx, args)
 	logger.Debug("Running", "command", args[0], "args", args[1:])
 
-	execCmd := exec.Command(args[0], args[1:]...)
-	execCmd.Stdout = os.Stdout
-	execCmd.Stderr = os.Stderr
-	if err := execCmd.Run(); err != nil {
-		os.Exit(execCmd.ProcessState.ExitCode())
+	if err := DefaultRunner.Run(ctx, args[0], args[1:]); err != nil {
+		return runError(err)
 	}
 
 	return nil
 }
 
+// ComposeFilePath returns the path of the rendered compose file.
+func ComposeFilePath(ctx context.Context) string {
+	return ctx.Value(ComposeFilePathKey{}).(string)
+}
+
+// ProjectDirectory returns the project directory of compose, relative paths of the
+// compose file are resolved against it. It defaults to the directory of the compose file.
+func ProjectDirectory(ctx context.Context) string {
+	composeArgs, _ := ctx.Value(ComposeArgsKey{}).([]string) //nolint:errcheck
+	if i := slices.Index(composeArgs, "--project-directory"); i >= 0 && i+1 < len(composeArgs) {
+		return composeArgs[i+1]
+	}
+
+	return filepath.Dir(ComposeFilePath(ctx))
+}
+
+// EnvFilesFromContext returns the env files passed to docker compose.
+func EnvFilesFromContext(ctx context.Context) []string {
+	envFiles, _ := ctx.Value(EnvFilesKey{}).([]string) //nolint:errcheck
+	return envFiles
+}
+
 // RunCompose is a function that is called to run a docker compose command.
 func RunCompose(ctx context.Context, args []string) error {
 	composeFilePath := ctx.Value(ComposeFilePathKey{}).(string)
 	composeCommand := ctx.Value(ComposeCommandKey{}).([]string)
 
-	args2 := append(composeCommand, []string{"-f", composeFilePath}...)
+	if err := checkBackendFlags(composeCommand, args); err != nil {
+		logger := ctx.Value(LoggerKey{}).(log.Logger)
+		logger.Error("Unsupported flag", "error", err)
+
+		return stageError(StageRun, err)
+	}
+
+	if err := checkComposeVersion(ctx, args); err != nil {
+		logger := ctx.Value(LoggerKey{}).(log.Logger)
+		logger.Error("Compose version too old", "error", err)
+
+		return stageError(StageRun, err)
+	}
+
+	composeArgs, _ := ctx.Value(ComposeArgsKey{}).([]string) //nolint:errcheck
+	envFiles := EnvFilesFromContext(ctx)
+
+	args2 := append([]string{}, composeCommand...)
+	args2 = append(args2, composeArgs...)
+
+	for _, envFile := range envFiles {
+		args2 = append(args2, "--env-file", envFile)
+	}
+
+	// Compose merges the files in order, so the rendered file is layered over the base files.
+	baseFiles := baseFilesFromContext(ctx)
+	for _, baseFile := range baseFiles {
+		args2 = append(args2, "-f", baseFile)
+	}
+
+	// The project directory defaults to the directory of the first file, keep it at the
+	// rendered file so its relative paths resolve as without base files.
+	if len(baseFiles) > 0 && !slices.Contains(composeArgs, "--project-directory") {
+		args2 = append(args2, "--project-directory", filepath.Dir(composeFilePath))
+	}
+
+	args2 = append(args2, "-f", composeFilePath)
+
+	overrides, _ := ctx.Value(OverrideFilesKey{}).([]string) //nolint:errcheck
+	for _, override := range overrides {
+		args2 = append(args2, "-f", override)
+	}
+
 	args2 = append(args2, args...)
 
 	return RunCmd(ctx, args2)
 }
+
+// RunComposeRetry runs a docker compose command and re-runs it up to retries times
+// when it fails, waiting delay between the attempts.
+// The error of the last attempt is returned if all attempts fail.
+func RunComposeRetry(ctx context.Context, args []string, retries int, delay time.Duration) error {
+	logger := ctx.Value(LoggerKey{}).(log.Logger)
+
+	err := RunCompose(ctx, args)
+	for attempt := 1; err != nil && attempt <= retries; attempt++ {
+		logger.Warn("Compose command failed, retrying", "attempt", attempt, "retries", retries, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+
+		err = RunCompose(ctx, args)
+	}
+
+	return err
+}
+
+// RunDocker runs a command of the container engine of the compose command, see EngineCommand.
+func RunDocker(ctx context.Context, args []string) error {
+	composeCommand := ctx.Value(ComposeCommandKey{}).([]string)
+
+	return RunCmd(ctx, append([]string{EngineCommand(composeCommand)}, args...))
+}
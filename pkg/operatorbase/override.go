@@ -0,0 +1,76 @@
+package operatorbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/go-orb/go-orb/codecs"
+)
+
+// OverrideFilesKey holds the compose files passed after the rendered one.
+type OverrideFilesKey struct{}
+
+//nolint:gochecknoglobals
+var (
+	stdinOnce    sync.Once
+	stdinContent []byte
+	errStdin     error
+)
+
+// readStdinOnce reads stdin once, so every project gets the same content.
+func readStdinOnce() ([]byte, error) {
+	stdinOnce.Do(func() {
+		stdinContent, errStdin = io.ReadAll(os.Stdin)
+	})
+
+	return stdinContent, errStdin
+}
+
+// WithStdinOverride reads a compose fragment from stdin, writes it next to the
+// rendered compose file and returns a context which passes it to compose after the rendered file.
+func WithStdinOverride(ctx context.Context) (context.Context, error) {
+	b, err := readStdinOnce()
+	if err != nil {
+		return ctx, fmt.Errorf("while reading the override from stdin: %w", err)
+	}
+
+	codec, err := codecs.GetMime(codecs.MimeYAML)
+	if err != nil {
+		return ctx, fmt.Errorf("while getting codec: %w", err)
+	}
+
+	var fragment map[string]any
+	if err := codec.Unmarshal(b, &fragment); err != nil {
+		return ctx, fmt.Errorf("while parsing the override from stdin: %w", err)
+	}
+
+	if len(fragment) == 0 {
+		return ctx, errors.New("the override from stdin is empty")
+	}
+
+	overridePath := filepath.Join(filepath.Dir(ComposeFilePath(ctx)), "override.yaml")
+	if err := os.WriteFile(overridePath, b, FileMode(ctx)); err != nil {
+		return ctx, fmt.Errorf("while writing the override file: %w", err)
+	}
+
+	// WriteFile keeps the mode of an existing file.
+	if err := os.Chmod(overridePath, FileMode(ctx)); err != nil {
+		return ctx, fmt.Errorf("while changing the file mode: %w", err)
+	}
+
+	return withOverrideFile(ctx, overridePath), nil
+}
+
+// withOverrideFile returns a context which passes path to compose after the
+// rendered file and the overrides already in ctx.
+func withOverrideFile(ctx context.Context, path string) context.Context {
+	overrides, _ := ctx.Value(OverrideFilesKey{}).([]string) //nolint:errcheck
+	overrides = append(append([]string{}, overrides...), path)
+
+	return context.WithValue(ctx, OverrideFilesKey{}, overrides)
+}
@@ -0,0 +1,96 @@
+package operatorbase
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// configHashLabel is the label compose stores the config hash of a container's service in.
+const configHashLabel = "com.docker.compose.config-hash"
+
+// Plan lists what docker compose up would do with the running stack.
+type Plan struct {
+	Create    []string `json:"create"`
+	Recreate  []string `json:"recreate"`
+	Unchanged []string `json:"unchanged"`
+	Orphaned  []string `json:"orphaned"`
+}
+
+// Empty reports whether up wouldn't change anything.
+func (p *Plan) Empty() bool {
+	return len(p.Create) == 0 && len(p.Recreate) == 0 && len(p.Orphaned) == 0
+}
+
+// label returns the value of a label from the comma separated labels of docker compose ps.
+func (c Container) label(name string) string {
+	for _, pair := range strings.Split(c.Labels, ",") {
+		if key, value, ok := strings.Cut(pair, "="); ok && key == name {
+			return value
+		}
+	}
+
+	return ""
+}
+
+// parseConfigHashes parses the "service hash" lines of docker compose config --hash.
+func parseConfigHashes(out []byte) map[string]string {
+	hashes := map[string]string{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 {
+			hashes[fields[0]] = fields[1]
+		}
+	}
+
+	return hashes
+}
+
+// PlanDeploy compares the config hashes of the rendered compose file
+// with the ones of the running containers.
+func PlanDeploy(ctx context.Context) (*Plan, error) {
+	out, err := ComposeOutput(ctx, []string{"config", "--hash", "*"})
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := parseConfigHashes(out)
+
+	containers, err := ListContainers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byService := map[string][]Container{}
+	for _, c := range containers {
+		byService[c.Service] = append(byService[c.Service], c)
+	}
+
+	plan := &Plan{Create: []string{}, Recreate: []string{}, Unchanged: []string{}, Orphaned: []string{}}
+
+	for _, name := range slices.Sorted(maps.Keys(hashes)) {
+		serviceContainers, ok := byService[name]
+
+		switch {
+		case !ok:
+			plan.Create = append(plan.Create, name)
+		case slices.ContainsFunc(serviceContainers, func(c Container) bool { return c.label(configHashLabel) != hashes[name] }):
+			plan.Recreate = append(plan.Recreate, name)
+		default:
+			plan.Unchanged = append(plan.Unchanged, name)
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(byService)) {
+		if _, ok := hashes[name]; !ok {
+			plan.Orphaned = append(plan.Orphaned, name)
+		}
+	}
+
+	return plan, nil
+}
@@ -0,0 +1,310 @@
+package operatorbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/go-orb/go-orb/log"
+	"github.com/octocompose/octoctl/pkg/octoconfig"
+	"github.com/urfave/cli/v3"
+)
+
+// Project is a rendered compose project.
+type Project struct {
+	Name            string
+	ComposeFilePath string
+	BaseFiles       []string
+	EnvFiles        []string
+	ComposeCommand  []string
+	Repo            octoconfig.Repo
+}
+
+// SplitProjects returns the config of every project.
+//
+// A config without a projects list is a single project. Otherwise every entry of
+// projects is merged over the remaining top level keys (repos, octoctl, ...),
+// so each project gets its own name and services.
+func SplitProjects(data map[string]any) ([]map[string]any, error) {
+	raw, ok := data["projects"]
+	if !ok {
+		normalizeProjectName(data)
+		return []map[string]any{data}, nil
+	}
+
+	list, ok := raw.([]any)
+	if !ok || len(list) == 0 {
+		return nil, errors.New("projects must be a non-empty list")
+	}
+
+	result := make([]map[string]any, 0, len(list))
+
+	for i, item := range list {
+		entry, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("projects[%d] must be a map", i)
+		}
+
+		if _, ok := projectName(entry); !ok {
+			return nil, fmt.Errorf("projects[%d] has no name", i)
+		}
+
+		// Deep copy so rendering a project never changes the config of another.
+		project := normalize(data).(map[string]any)
+		delete(project, "projects")
+		delete(project, "services")
+
+		delete(project, "name")
+		delete(project, "projectID")
+
+		for key, value := range normalize(entry).(map[string]any) {
+			project[key] = value
+		}
+
+		normalizeProjectName(project)
+
+		result = append(result, project)
+	}
+
+	return result, nil
+}
+
+// projectName returns the project name of a config, configs written for
+// operator-docker-compose use projectID which is preferred over name.
+func projectName(data map[string]any) (string, bool) {
+	if projectID, ok := data["projectID"].(string); ok && projectID != "" {
+		return projectID, true
+	}
+
+	name, ok := data["name"].(string)
+
+	return name, ok && name != ""
+}
+
+// composeProjectName normalizes name like compose does: lowercase, only
+// letters, digits, dashes and underscores, starting with a letter or digit.
+// Compose prefixes the names of volumes and networks with it.
+func composeProjectName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' || r == '-' {
+			return r
+		}
+
+		return -1
+	}, strings.ToLower(name))
+
+	return strings.TrimLeft(name, "_-")
+}
+
+// normalizeProjectName stores the compose project name of data as name, the key compose understands.
+func normalizeProjectName(data map[string]any) {
+	if name, ok := projectName(data); ok {
+		data["name"] = composeProjectName(name)
+	}
+
+	delete(data, "projectID")
+}
+
+// renderProject prepares and writes the compose file of a single project.
+func renderProject(
+	logger log.Logger, cmd *cli.Command, data map[string]any, fileMode os.FileMode, composeCommand []string,
+) (Project, error) {
+	projectID, ok := data["name"].(string)
+	if !ok || projectID == "" {
+		logger.Error("Project name not found")
+		return Project{}, stageError(StagePrepare, errors.New("project name not found"))
+	}
+
+	logger = logger.With("project", projectID)
+
+	variables, err := Variables(data)
+	if err != nil {
+		logger.Error("Error while reading the variables", "error", err)
+		return Project{}, stageError(StagePrepare, err)
+	}
+
+	repo, err := LoadRepo(data)
+	if err != nil {
+		logger.Error("Error while parsing config", "error", err)
+		return Project{}, stageError(StagePrepare, err)
+	}
+
+	// A project may declare its own backend, e.g. podman.
+	composeCommand, err = resolveComposeCommand(cmd, data, composeCommand)
+	if err != nil {
+		logger.Error("Error while resolving the compose command", "error", err)
+		return Project{}, stageError(StageRun, err)
+	}
+
+	baseFiles, err := BaseFiles(cmd, data)
+	if err != nil {
+		logger.Error("Error while reading the base files", "error", err)
+		return Project{}, stageError(StagePrepare, err)
+	}
+
+	data, err = PrepareConfig(logger, cmd, data, repo)
+	if err != nil {
+		logger.Error("Error while reading and preparing config", "error", err)
+		return Project{}, stageError(StagePrepare, err)
+	}
+
+	logger.Trace("Resolved config", "config", Redact(data, redactPatterns(cmd)))
+
+	services, _ := data["services"].(map[string]any) //nolint:errcheck
+	recordRenderedServices(projectID, len(services))
+
+	composeFilePath, err := WriteConfig(logger, cmd, data, projectID, fileMode)
+	if err != nil {
+		logger.Error("Error while writing config", "error", err)
+		return Project{}, stageError(StageWrite, err)
+	}
+
+	if err := WriteEnvFile(logger, filepath.Dir(composeFilePath), variables, fileMode); err != nil {
+		logger.Error("Error while writing the env file", "error", err)
+		return Project{}, stageError(StageWrite, err)
+	}
+
+	envFiles, err := EnvFiles(
+		cmd.String("config"),
+		!cmd.Bool("no-env-file-discovery"),
+		filepath.Join(filepath.Dir(composeFilePath), ".env"),
+		len(variables) > 0,
+	)
+	if err != nil {
+		logger.Error("Error while discovering the env file", "error", err)
+		return Project{}, stageError(StagePrepare, err)
+	}
+
+	if len(envFiles) > 0 {
+		logger.Debug("Using env files", "files", envFiles)
+	}
+
+	return Project{
+		Name:            projectID,
+		ComposeFilePath: composeFilePath,
+		BaseFiles:       baseFiles,
+		EnvFiles:        envFiles,
+		ComposeCommand:  composeCommand,
+		Repo:            repo,
+	}, nil
+}
+
+// Projects returns the projects rendered by BeforeConfig.
+func Projects(ctx context.Context) []Project {
+	projects, _ := ctx.Value(ProjectsKey{}).([]Project) //nolint:errcheck
+	return projects
+}
+
+// CurrentProject returns the project commands run against.
+func CurrentProject(ctx context.Context) Project {
+	return ctx.Value(ProjectKey{}).(Project)
+}
+
+// WithProject returns a context to run compose commands against project.
+func WithProject(ctx context.Context, project Project) context.Context {
+	ctx = context.WithValue(ctx, ProjectKey{}, project)
+	ctx = context.WithValue(ctx, ComposeFilePathKey{}, project.ComposeFilePath)
+	ctx = context.WithValue(ctx, BaseFilesKey{}, project.BaseFiles)
+	ctx = context.WithValue(ctx, EnvFilesKey{}, project.EnvFiles)
+	ctx = context.WithValue(ctx, RepoKey{}, project.Repo)
+
+	if len(project.ComposeCommand) > 0 {
+		ctx = context.WithValue(ctx, ComposeCommandKey{}, project.ComposeCommand)
+	}
+
+	return ctx
+}
+
+// Stdout returns the writer for the output of commands.
+func Stdout(ctx context.Context) io.Writer {
+	if w, ok := ctx.Value(StdoutKey{}).(io.Writer); ok {
+		return w
+	}
+
+	return os.Stdout
+}
+
+// FanOut runs action once per project, one project after the other.
+// With several projects the output of each is prefixed with the project name.
+func FanOut(action cli.ActionFunc) cli.ActionFunc {
+	return func(ctx context.Context, cmd *cli.Command) error {
+		projects := Projects(ctx)
+		if len(projects) <= 1 {
+			return action(ctx, cmd)
+		}
+
+		for _, project := range projects {
+			if err := runForProject(ctx, cmd, action, project); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// FanOutParallel runs action for all projects at the same time,
+// for long running commands like logs --follow.
+func FanOutParallel(action cli.ActionFunc) cli.ActionFunc {
+	return func(ctx context.Context, cmd *cli.Command) error {
+		projects := Projects(ctx)
+		if len(projects) <= 1 {
+			return action(ctx, cmd)
+		}
+
+		var (
+			wg   sync.WaitGroup
+			mu   sync.Mutex
+			errs []error
+		)
+
+		for _, project := range projects {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				if err := runForProject(ctx, cmd, action, project); err != nil {
+					mu.Lock()
+					errs = append(errs, err)
+					mu.Unlock()
+				}
+			}()
+		}
+
+		wg.Wait()
+
+		return errors.Join(errs...)
+	}
+}
+
+// Single makes sure action only runs when there's a single project.
+func Single(action cli.ActionFunc) cli.ActionFunc {
+	return func(ctx context.Context, cmd *cli.Command) error {
+		if len(Projects(ctx)) > 1 {
+			return fmt.Errorf("the command '%s' needs a single project, select one with --project", cmd.Name)
+		}
+
+		return action(ctx, cmd)
+	}
+}
+
+func runForProject(ctx context.Context, cmd *cli.Command, action cli.ActionFunc, project Project) error {
+	stdout := newPrefixWriter(Stdout(ctx), "["+project.Name+"] ")
+
+	ctx = WithProject(ctx, project)
+	ctx = context.WithValue(ctx, StdoutKey{}, stdout)
+
+	err := action(ctx, cmd)
+
+	if fErr := stdout.Flush(); fErr != nil && err == nil {
+		err = fErr
+	}
+
+	return err
+}
@@ -0,0 +1,120 @@
+package operatorbase
+
+import (
+	"testing"
+)
+
+func TestProjectName(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       map[string]any
+		wantName   string
+		wantOK     bool
+		normalized any
+	}{
+		{
+			name:       "projectID only",
+			data:       map[string]any{"projectID": "myproject"},
+			wantName:   "myproject",
+			wantOK:     true,
+			normalized: "myproject",
+		},
+		{
+			name:       "name only",
+			data:       map[string]any{"name": "myproject"},
+			wantName:   "myproject",
+			wantOK:     true,
+			normalized: "myproject",
+		},
+		{
+			name:       "projectID wins over name",
+			data:       map[string]any{"projectID": "fromid", "name": "fromname"},
+			wantName:   "fromid",
+			wantOK:     true,
+			normalized: "fromid",
+		},
+		{
+			name:       "empty projectID falls back to name",
+			data:       map[string]any{"projectID": "", "name": "fromname"},
+			wantName:   "fromname",
+			wantOK:     true,
+			normalized: "fromname",
+		},
+		{
+			name:       "normalized like compose",
+			data:       map[string]any{"projectID": "_My.Project-1"},
+			wantName:   "_My.Project-1",
+			wantOK:     true,
+			normalized: "myproject-1",
+		},
+		{
+			name:       "non string name",
+			data:       map[string]any{"name": 42},
+			wantName:   "",
+			wantOK:     false,
+			normalized: 42,
+		},
+		{
+			name:       "neither",
+			data:       map[string]any{},
+			wantName:   "",
+			wantOK:     false,
+			normalized: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, ok := projectName(tt.data)
+			if name != tt.wantName || ok != tt.wantOK {
+				t.Fatalf("projectName() = %q, %v, want %q, %v", name, ok, tt.wantName, tt.wantOK)
+			}
+
+			normalizeProjectName(tt.data)
+
+			if _, ok := tt.data["projectID"]; ok {
+				t.Fatalf("normalizeProjectName() kept projectID")
+			}
+
+			if got := tt.data["name"]; got != tt.normalized {
+				t.Fatalf("normalizeProjectName() name = %v, want %v", got, tt.normalized)
+			}
+		})
+	}
+}
+
+func TestSplitProjects(t *testing.T) {
+	data := map[string]any{
+		"name": "top",
+		"services": map[string]any{
+			"web": map[string]any{"image": "nginx"},
+		},
+		"projects": []any{
+			map[string]any{"projectID": "First"},
+			map[string]any{"name": "second"},
+		},
+	}
+
+	projects, err := SplitProjects(data)
+	if err != nil {
+		t.Fatalf("SplitProjects() error = %v", err)
+	}
+
+	if len(projects) != 2 {
+		t.Fatalf("SplitProjects() returned %d projects, want 2", len(projects))
+	}
+
+	for i, want := range []string{"first", "second"} {
+		if got := projects[i]["name"]; got != want {
+			t.Errorf("projects[%d] name = %v, want %s", i, got, want)
+		}
+
+		if _, ok := projects[i]["services"]; ok {
+			t.Errorf("projects[%d] inherited the top level services", i)
+		}
+	}
+
+	if _, err := SplitProjects(map[string]any{"projects": []any{map[string]any{}}}); err == nil {
+		t.Errorf("SplitProjects() without a project name succeeded")
+	}
+}
@@ -0,0 +1,185 @@
+package operatorbase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/go-orb/go-orb/log"
+)
+
+// Container is a container as reported by docker compose ps --format json.
+type Container struct {
+	ID       string `json:"ID"`
+	Name     string `json:"Name"`
+	Service  string `json:"Service"`
+	Project  string `json:"Project"`
+	Image    string `json:"Image"`
+	State    string `json:"State"`
+	Health   string `json:"Health"`
+	Status   string `json:"Status"`
+	ExitCode int    `json:"ExitCode"`
+	Labels   string `json:"Labels"`
+}
+
+// ComposeOutput runs a docker compose command and returns its stdout.
+func ComposeOutput(ctx context.Context, args []string) ([]byte, error) {
+	var buf bytes.Buffer
+
+	ctx = context.WithValue(ctx, StdoutKey{}, &buf)
+	if err := RunCompose(ctx, args); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ListContainers returns all containers of the project.
+func ListContainers(ctx context.Context) ([]Container, error) {
+	out, err := ComposeOutput(ctx, []string{"ps", "-a", "--format", "json"})
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseContainers(out)
+}
+
+// ParseContainers parses the output of docker compose ps --format json,
+// which is a JSON array in older compose versions and JSON lines in newer ones.
+func ParseContainers(out []byte) ([]Container, error) {
+	out = bytes.TrimSpace(out)
+	if len(out) == 0 {
+		return []Container{}, nil
+	}
+
+	if out[0] == '[' {
+		var containers []Container
+		if err := json.Unmarshal(out, &containers); err != nil {
+			return nil, fmt.Errorf("while parsing the container list: %w", err)
+		}
+
+		return containers, nil
+	}
+
+	containers := []Container{}
+
+	for _, line := range bytes.Split(out, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		var c Container
+		if err := json.Unmarshal(line, &c); err != nil {
+			return nil, fmt.Errorf("while parsing the container list: %w", err)
+		}
+
+		containers = append(containers, c)
+	}
+
+	return containers, nil
+}
+
+// InSync reports whether the rendered compose file has been deployed unchanged
+// and every service of it has a running container.
+func InSync(ctx context.Context) (bool, error) {
+	logger := ctx.Value(LoggerKey{}).(log.Logger)
+	composeFilePath := ComposeFilePath(ctx)
+
+	hash, err := FileHash(composeFilePath)
+	if err != nil {
+		return false, err
+	}
+
+	lastHash, err := LastDeployHash(composeFilePath)
+	if err != nil {
+		return false, err
+	}
+
+	if hash != lastHash {
+		logger.Debug("Compose file changed since the last deploy")
+		return false, nil
+	}
+
+	rendered, err := LoadComposeFile(composeFilePath)
+	if err != nil {
+		return false, err
+	}
+
+	containers, err := ListContainers(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	running := map[string]bool{}
+	for _, c := range containers {
+		if c.State == "running" {
+			running[c.Service] = true
+		}
+	}
+
+	services, _ := rendered["services"].(map[string]any) //nolint:errcheck
+	for name := range services {
+		if !running[name] {
+			logger.Debug("Service is not running", "service", name)
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// UnhealthyService is a service that isn't running or isn't healthy.
+type UnhealthyService struct {
+	Service string
+	Reason  string
+}
+
+// CheckServices returns the services of the rendered compose file which have no running
+// container, or whose container reports a health other than healthy.
+func CheckServices(ctx context.Context) ([]UnhealthyService, error) {
+	rendered, err := LoadComposeFile(ComposeFilePath(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	containers, err := ListContainers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byService := map[string][]Container{}
+	for _, c := range containers {
+		byService[c.Service] = append(byService[c.Service], c)
+	}
+
+	services, _ := rendered["services"].(map[string]any) //nolint:errcheck
+
+	unhealthy := []UnhealthyService{}
+
+	for _, name := range slices.Sorted(maps.Keys(services)) {
+		serviceContainers := byService[name]
+		if len(serviceContainers) == 0 {
+			unhealthy = append(unhealthy, UnhealthyService{Service: name, Reason: "no container"})
+			continue
+		}
+
+		for _, c := range serviceContainers {
+			if c.State != "running" {
+				unhealthy = append(unhealthy, UnhealthyService{Service: name, Reason: "container " + c.Name + " is " + c.State})
+				break
+			}
+
+			// Health is empty for containers without a healthcheck.
+			if c.Health != "" && c.Health != "healthy" {
+				unhealthy = append(unhealthy, UnhealthyService{Service: name, Reason: "container " + c.Name + " is " + c.Health})
+				break
+			}
+		}
+	}
+
+	return unhealthy, nil
+}
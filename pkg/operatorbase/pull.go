@@ -0,0 +1,45 @@
+package operatorbase
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// PullPolicies are the values docker compose accepts for up --pull.
+//
+//nolint:gochecknoglobals
+var PullPolicies = []string{"always", "missing", "never"}
+
+// ValidatePullPolicy returns an error if policy isn't one of PullPolicies.
+func ValidatePullPolicy(policy string) error {
+	if !slices.Contains(PullPolicies, policy) {
+		return fmt.Errorf("invalid pull policy '%s', expected %s", policy, strings.Join(PullPolicies, ", "))
+	}
+
+	return nil
+}
+
+// ServicePullPolicies are the values docker compose accepts for the pull_policy of a service.
+//
+//nolint:gochecknoglobals
+var ServicePullPolicies = []string{"always", "missing", "never", "build"}
+
+// applyPullPolicy renders policy into the pull_policy of svc, unless svc has one.
+func applyPullPolicy(svc map[string]any, policy string) error {
+	if policy == "" {
+		return nil
+	}
+
+	if _, ok := svc["pull_policy"]; ok {
+		return nil
+	}
+
+	if !slices.Contains(ServicePullPolicies, policy) {
+		return fmt.Errorf("octocompose.pullPolicy has an invalid value '%s', expected %s", policy, strings.Join(ServicePullPolicies, ", "))
+	}
+
+	svc["pull_policy"] = policy
+
+	return nil
+}
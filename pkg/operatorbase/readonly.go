@@ -0,0 +1,61 @@
+package operatorbase
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/go-orb/go-orb/log"
+	"github.com/urfave/cli/v3"
+)
+
+// readOnlyCommands are the commands which only inspect a stack,
+// every other command is considered mutating.
+//
+//nolint:gochecknoglobals
+var readOnlyCommands = map[string]bool{
+	"status":   true,
+	"logs":     true,
+	"show":     true,
+	"config":   true,
+	"images":   true,
+	"diff":     true,
+	"export":   true,
+	"ls":       true,
+	"top":      true,
+	"events":   true,
+	"verify":   true,
+	"validate": true,
+	"schema":   true,
+	"inspect":  true,
+}
+
+// IsReadOnlyCommand reports whether the command with the given name never changes a stack.
+func IsReadOnlyCommand(name string) bool {
+	return readOnlyCommands[name]
+}
+
+// ForceWriteEnv is the environment variable which acknowledges --force-write,
+// it must hold the name of the command to run.
+const ForceWriteEnv = "OCTOCOMPOSE_FORCE_WRITE"
+
+// checkReadOnly refuses mutating commands when the read-only flag is set.
+//
+// The override is awkward on purpose: --force-write only works together with
+// ForceWriteEnv set to the command name, so it can't be left on by accident.
+func checkReadOnly(logger log.Logger, cmd *cli.Command) error {
+	if !cmd.Bool("read-only") || IsReadOnlyCommand(cmd.Name) {
+		return nil
+	}
+
+	if cmd.Bool("force-write") {
+		if os.Getenv(ForceWriteEnv) != cmd.Name {
+			return fmt.Errorf("--force-write requires %s=%s", ForceWriteEnv, cmd.Name)
+		}
+
+		logger.Warn("!!! FORCING A MUTATING COMMAND IN READ-ONLY MODE !!!", "command", cmd.Name, "user", os.Getenv("USER"))
+
+		return nil
+	}
+
+	return fmt.Errorf("the command '%s' changes the stack and is not allowed in read-only mode", cmd.Name)
+}
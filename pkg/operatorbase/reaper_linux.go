@@ -0,0 +1,64 @@
+//go:build linux
+
+package operatorbase
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// StartReaper reaps exited orphans when the operator runs as PID 1, like in a container,
+// so a long running daemon doesn't accumulate defunct processes. It stops when ctx is done.
+func StartReaper(ctx context.Context) {
+	if os.Getpid() != 1 {
+		return
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGCHLD)
+
+	go func() {
+		defer signal.Stop(sigs)
+
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+
+		pending := false
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-sigs:
+				pending = true
+			case <-ticker.C:
+			}
+
+			if pending && reapChildren() {
+				pending = false
+			}
+		}
+	}()
+}
+
+// reapChildren waits for every exited child. It only runs while no Runner waits
+// for a child, so it never steals the exit status of one of our commands,
+// it returns false when it has to be retried.
+func reapChildren() bool {
+	if !childrenMu.TryLock() {
+		return false
+	}
+	defer childrenMu.Unlock()
+
+	for {
+		var status syscall.WaitStatus
+
+		pid, err := syscall.Wait4(-1, &status, syscall.WNOHANG, nil)
+		if pid <= 0 || err != nil {
+			return true
+		}
+	}
+}
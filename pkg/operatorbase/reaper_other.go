@@ -0,0 +1,8 @@
+//go:build !linux
+
+package operatorbase
+
+import "context"
+
+// StartReaper reaps exited orphans when the operator runs as PID 1, it's only supported on Linux.
+func StartReaper(_ context.Context) {}
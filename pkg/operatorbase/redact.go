@@ -0,0 +1,95 @@
+package operatorbase
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+)
+
+// RedactKey holds the key patterns whose values are masked in trace logs.
+type RedactKey struct{}
+
+// redacted replaces sensitive values in logs.
+const redacted = "***"
+
+// redactPatterns returns the redact-keys patterns, or nil when redact-config-in-logs is off.
+func redactPatterns(cmd *cli.Command) []string {
+	if !cmd.Bool("redact-config-in-logs") {
+		return nil
+	}
+
+	return cmd.StringSlice("redact-keys")
+}
+
+// isSensitiveKey reports whether key matches one of the glob patterns, ignoring case.
+func isSensitiveKey(key string, patterns []string) bool {
+	key = strings.ToLower(key)
+
+	for _, pattern := range patterns {
+		if ok, err := path.Match(strings.ToLower(pattern), key); err == nil && ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Redact returns a copy of v with the values of sensitive keys masked,
+// KEY=VALUE strings in lists (like environment) are masked by their key.
+func Redact(v any, patterns []string) any {
+	if len(patterns) == 0 {
+		return v
+	}
+
+	switch value := v.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(value))
+
+		for key, item := range value {
+			if isSensitiveKey(key, patterns) {
+				result[key] = redacted
+			} else {
+				result[key] = Redact(item, patterns)
+			}
+		}
+
+		return result
+	case []any:
+		result := make([]any, len(value))
+		for i, item := range value {
+			result[i] = Redact(item, patterns)
+		}
+
+		return result
+	case string:
+		if key, _, ok := strings.Cut(value, "="); ok && isSensitiveKey(key, patterns) {
+			return key + "=" + redacted
+		}
+
+		return value
+	default:
+		return v
+	}
+}
+
+// RedactEnv returns env with the values of sensitive KEY=VALUE entries masked.
+func RedactEnv(env []string, patterns []string) []string {
+	if len(patterns) == 0 {
+		return env
+	}
+
+	result := make([]string, len(env))
+	for i, entry := range env {
+		result[i] = Redact(entry, patterns).(string)
+	}
+
+	return result
+}
+
+// redactPatternsFromContext returns the patterns stored by BeforeConfig.
+func redactPatternsFromContext(ctx context.Context) []string {
+	patterns, _ := ctx.Value(RedactKey{}).([]string) //nolint:errcheck
+	return patterns
+}
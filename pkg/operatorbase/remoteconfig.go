@@ -0,0 +1,76 @@
+package operatorbase
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// IsConfigURL reports whether the config is fetched over http(s) instead of read from a file.
+func IsConfigURL(config string) bool {
+	return strings.HasPrefix(config, "http://") || strings.HasPrefix(config, "https://")
+}
+
+// fetchConfig fetches the config with a GET request,
+// it returns the body and the content type of the response.
+func fetchConfig(ctx context.Context, configURL, token string, timeout time.Duration, maxSize int64) ([]byte, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, configURL, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("while creating the request: %w", err)
+	}
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("while fetching the config: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("while fetching the config: unexpected status %s", resp.Status)
+	}
+
+	b, err := readLimited(resp.Body, maxSize)
+	if err != nil {
+		return nil, "", fmt.Errorf("while reading the config response: %w", err)
+	}
+
+	return b, resp.Header.Get("Content-Type"), nil
+}
+
+// urlConfigFormat returns the config format of a fetched config from the
+// extension of the URL path or else from the content type of the response.
+func urlConfigFormat(configURL, contentType string) (string, error) {
+	if u, err := url.Parse(configURL); err == nil {
+		if format := extConfigFormat(path.Ext(u.Path)); format != "" {
+			return format, nil
+		}
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", fmt.Errorf("unable to detect the config format of '%s', set --config-format", configURL)
+	}
+
+	switch mediaType {
+	case "application/json":
+		return "json", nil
+	case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+		return "yaml", nil
+	case "application/toml", "text/toml":
+		return "toml", nil
+	default:
+		return "", fmt.Errorf("unexpected content type '%s' of '%s', set --config-format", mediaType, configURL)
+	}
+}
@@ -0,0 +1,54 @@
+package operatorbase
+
+import (
+	"fmt"
+)
+
+// translateReplicas moves deploy.replicas of svc to scale, which plain
+// docker compose honors without --compatibility. An explicit scale wins.
+// It returns the number of replicas and whether svc had deploy.replicas.
+func translateReplicas(svc map[string]any) (int, bool, error) {
+	deploy, ok := svc["deploy"].(map[string]any)
+	if !ok {
+		return 0, false, nil
+	}
+
+	raw, ok := deploy["replicas"]
+	if !ok {
+		return 0, false, nil
+	}
+
+	var replicas int
+
+	switch v := raw.(type) {
+	case int:
+		replicas = v
+	case int64:
+		replicas = int(v)
+	case uint64:
+		replicas = int(v) //nolint:gosec
+	case float64:
+		replicas = int(v)
+		if float64(replicas) != v {
+			return 0, false, fmt.Errorf("deploy.replicas must be a whole number, got %v", v)
+		}
+	default:
+		return 0, false, fmt.Errorf("deploy.replicas must be a number, got %T", raw)
+	}
+
+	if replicas < 0 {
+		return 0, false, fmt.Errorf("deploy.replicas must not be negative, got %d", replicas)
+	}
+
+	delete(deploy, "replicas")
+
+	if len(deploy) == 0 {
+		delete(svc, "deploy")
+	}
+
+	if _, ok := svc["scale"]; !ok {
+		svc["scale"] = replicas
+	}
+
+	return replicas, true, nil
+}
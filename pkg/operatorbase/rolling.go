@@ -0,0 +1,86 @@
+package operatorbase
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-orb/go-orb/log"
+)
+
+// rollingPollInterval is how often RollingRestart checks a restarted container.
+const rollingPollInterval = 2 * time.Second
+
+// RollingRestart restarts the running containers of services (all when empty) one at a time,
+// waiting up to timeout for each to be running and healthy before restarting the next.
+func RollingRestart(ctx context.Context, services []string, timeout time.Duration) error {
+	logger := ctx.Value(LoggerKey{}).(log.Logger)
+
+	containers, err := ListContainers(ctx)
+	if err != nil {
+		return err
+	}
+
+	containers = slices.DeleteFunc(containers, func(c Container) bool {
+		return c.State != "running" || (len(services) > 0 && !slices.Contains(services, c.Service))
+	})
+
+	slices.SortFunc(containers, func(a, b Container) int {
+		if c := strings.Compare(a.Service, b.Service); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	for _, c := range containers {
+		logger.Info("Restarting", "service", c.Service, "container", c.Name)
+
+		if err := RunDocker(ctx, []string{"restart", c.Name}); err != nil {
+			return err
+		}
+
+		if err := waitHealthy(ctx, c.Name, timeout); err != nil {
+			logger.Error("Service did not become healthy", "service", c.Service, "container", c.Name, "error", err)
+			return &Error{Stage: StageRun, Service: c.Service, ExitCode: 1, Err: err}
+		}
+	}
+
+	return nil
+}
+
+// waitHealthy waits until the container is running and, when it has a healthcheck, healthy.
+func waitHealthy(ctx context.Context, name string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(rollingPollInterval)
+	defer ticker.Stop()
+
+	for {
+		containers, err := ListContainers(ctx)
+		if err != nil {
+			return err
+		}
+
+		idx := slices.IndexFunc(containers, func(c Container) bool { return c.Name == name })
+		if idx >= 0 {
+			c := containers[idx]
+
+			switch {
+			case c.Health == "unhealthy":
+				return fmt.Errorf("container %s is unhealthy after the restart", name)
+			case c.State == "running" && (c.Health == "" || c.Health == "healthy"):
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("container %s didn't become healthy within %s", name, timeout)
+		case <-ticker.C:
+		}
+	}
+}
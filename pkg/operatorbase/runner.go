@@ -0,0 +1,88 @@
+package operatorbase
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/go-orb/go-orb/log"
+)
+
+// Runner runs an external command.
+type Runner interface {
+	Run(ctx context.Context, name string, args []string) error
+}
+
+// StdinKey holds the reader child processes read their stdin from, they get no stdin without it.
+type StdinKey struct{}
+
+// childrenMu is held for reading while a child process runs,
+// the reaper takes it for writing so it doesn't reap our own children.
+//
+//nolint:gochecknoglobals
+var childrenMu sync.RWMutex
+
+// ExecRunner is the Runner which executes commands with os/exec.
+type ExecRunner struct{}
+
+// Run implements Runner.
+func (ExecRunner) Run(ctx context.Context, name string, args []string) error {
+	childrenMu.RLock()
+	defer childrenMu.RUnlock()
+
+	execCmd := exec.CommandContext(ctx, name, args...)
+
+	// Give the command a chance to shut down cleanly when the context is done.
+	execCmd.Cancel = func() error { return execCmd.Process.Signal(os.Interrupt) }
+	execCmd.WaitDelay = 10 * time.Second
+
+	if env := childEnv(ctx); len(env) > 0 {
+		execCmd.Env = append(os.Environ(), env...)
+	}
+
+	if logger, ok := ctx.Value(LoggerKey{}).(log.Logger); ok {
+		logger.Trace("Running with environment", "command", name, "env", RedactEnv(execCmd.Environ(), redactPatternsFromContext(ctx)))
+	}
+
+	if r, ok := ctx.Value(StdinKey{}).(io.Reader); ok {
+		execCmd.Stdin = r
+	}
+
+	var stdout io.Writer = os.Stdout
+	if w, ok := ctx.Value(StdoutKey{}).(io.Writer); ok {
+		stdout = w
+	}
+
+	var stderr io.Writer = os.Stderr
+	if w, ok := ctx.Value(StderrKey{}).(io.Writer); ok {
+		stderr = w
+	}
+
+	if lineBuffered, ok := ctx.Value(LineBufferedKey{}).(bool); ok && lineBuffered {
+		stdout := newLineWriter(stdout)
+		stderr := newLineWriter(stderr)
+
+		execCmd.Stdout = stdout
+		execCmd.Stderr = stderr
+
+		err := execCmd.Run()
+
+		_ = stdout.Flush()
+		_ = stderr.Flush()
+
+		return err
+	}
+
+	execCmd.Stdout = stdout
+	execCmd.Stderr = stderr
+
+	return execCmd.Run()
+}
+
+// DefaultRunner is the Runner used by RunCmd, tests may replace it.
+//
+//nolint:gochecknoglobals
+var DefaultRunner Runner = ExecRunner{}
@@ -0,0 +1,156 @@
+package operatorbase
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/go-orb/go-orb/log"
+
+	_ "github.com/go-orb/plugins/codecs/json"
+	_ "github.com/go-orb/plugins/log/slog"
+)
+
+// fakeRunner records the commands instead of running them.
+type fakeRunner struct {
+	calls [][]string
+}
+
+func (r *fakeRunner) Run(_ context.Context, name string, args []string) error {
+	r.calls = append(r.calls, append([]string{name}, args...))
+	return nil
+}
+
+// withFakeRunner replaces DefaultRunner for the duration of the test.
+func withFakeRunner(t *testing.T) *fakeRunner {
+	t.Helper()
+
+	runner := &fakeRunner{}
+	previous := DefaultRunner
+	DefaultRunner = runner
+
+	t.Cleanup(func() { DefaultRunner = previous })
+
+	return runner
+}
+
+func testLogger(t *testing.T) log.Logger {
+	t.Helper()
+
+	logger, err := log.New(log.WithLevel("error"))
+	if err != nil {
+		t.Fatalf("log.New() error = %v", err)
+	}
+
+	return logger
+}
+
+func testContext(t *testing.T) context.Context {
+	t.Helper()
+
+	ctx := context.WithValue(context.Background(), LoggerKey{}, testLogger(t))
+	ctx = context.WithValue(ctx, ComposeCommandKey{}, []string{"docker", "compose"})
+
+	return context.WithValue(ctx, ComposeFilePathKey{}, "/run/octocompose/app/compose.yaml")
+}
+
+func TestRunComposeArgv(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  func(ctx context.Context) context.Context
+		args []string
+		want []string
+	}{
+		{
+			name: "compose file only",
+			ctx:  func(ctx context.Context) context.Context { return ctx },
+			args: []string{"up", "-d"},
+			want: []string{"docker", "compose", "-f", "/run/octocompose/app/compose.yaml", "up", "-d"},
+		},
+		{
+			name: "compose args and env files",
+			ctx: func(ctx context.Context) context.Context {
+				ctx = context.WithValue(ctx, ComposeArgsKey{}, []string{"--progress", "plain"})
+				return context.WithValue(ctx, EnvFilesKey{}, []string{"/etc/app/.env", "/run/octocompose/app/.env"})
+			},
+			args: []string{"ps"},
+			want: []string{
+				"docker", "compose", "--progress", "plain",
+				"--env-file", "/etc/app/.env", "--env-file", "/run/octocompose/app/.env",
+				"-f", "/run/octocompose/app/compose.yaml", "ps",
+			},
+		},
+		{
+			name: "base and override files",
+			ctx: func(ctx context.Context) context.Context {
+				ctx = context.WithValue(ctx, BaseFilesKey{}, []string{"/etc/app/base.yaml"})
+				return context.WithValue(ctx, OverrideFilesKey{}, []string{"/run/octocompose/app/override.yaml"})
+			},
+			args: []string{"config"},
+			want: []string{
+				"docker", "compose", "-f", "/etc/app/base.yaml",
+				"--project-directory", "/run/octocompose/app",
+				"-f", "/run/octocompose/app/compose.yaml",
+				"-f", "/run/octocompose/app/override.yaml", "config",
+			},
+		},
+		{
+			name: "sudo preserves the env",
+			ctx: func(ctx context.Context) context.Context {
+				ctx = context.WithValue(ctx, SudoCommandKey{}, []string{"sudo", "-n"})
+				return context.WithValue(ctx, EnvKey{}, map[string]string{"B": "2", "A": "1"})
+			},
+			args: []string{"down"},
+			want: []string{
+				"sudo", "-n", "--preserve-env=A,B",
+				"docker", "compose", "-f", "/run/octocompose/app/compose.yaml", "down",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := withFakeRunner(t)
+
+			if err := RunCompose(tt.ctx(testContext(t)), tt.args); err != nil {
+				t.Fatalf("RunCompose() error = %v", err)
+			}
+
+			if len(runner.calls) != 1 {
+				t.Fatalf("RunCompose() ran %d commands, want 1", len(runner.calls))
+			}
+
+			if !slices.Equal(runner.calls[0], tt.want) {
+				t.Errorf("RunCompose() argv = %q, want %q", runner.calls[0], tt.want)
+			}
+		})
+	}
+}
+
+func TestRunComposeUnsupportedFlag(t *testing.T) {
+	runner := withFakeRunner(t)
+
+	ctx := context.WithValue(testContext(t), ComposeCommandKey{}, []string{"podman-compose"})
+
+	if err := RunCompose(ctx, []string{"up", "--pull", "always"}); err == nil {
+		t.Fatalf("RunCompose() with --pull on podman-compose succeeded")
+	}
+
+	if len(runner.calls) != 0 {
+		t.Errorf("RunCompose() ran %q", runner.calls)
+	}
+}
+
+func TestRunDockerEngine(t *testing.T) {
+	runner := withFakeRunner(t)
+
+	ctx := context.WithValue(testContext(t), ComposeCommandKey{}, []string{"docker-compose"})
+
+	if err := RunDocker(ctx, []string{"volume", "rm", "app_data"}); err != nil {
+		t.Fatalf("RunDocker() error = %v", err)
+	}
+
+	if want := []string{"docker", "volume", "rm", "app_data"}; len(runner.calls) != 1 || !slices.Equal(runner.calls[0], want) {
+		t.Errorf("RunDocker() ran %q, want %q", runner.calls, want)
+	}
+}
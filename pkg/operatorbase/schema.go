@@ -0,0 +1,137 @@
+package operatorbase
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/go-orb/go-orb/config"
+	"github.com/octocompose/octoctl/pkg/octoconfig"
+)
+
+// schemaOverrides are the schemas of types with a custom JSON encoding.
+//
+//nolint:gochecknoglobals
+var schemaOverrides = map[reflect.Type]map[string]any{
+	reflect.TypeFor[config.URL](): {"type": "string", "format": "uri-reference"},
+	reflect.TypeFor[DependsOn](): {"oneOf": []any{
+		map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		map[string]any{"type": "object", "additionalProperties": map[string]any{"enum": []any{
+			"service_started", "service_healthy", "service_completed_successfully",
+		}}},
+	}},
+	reflect.TypeFor[HealthcheckTest](): {"oneOf": []any{
+		map[string]any{"type": "string"},
+		map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+	}},
+}
+
+// typeSchema returns the JSON schema of the JSON encoding of t.
+func typeSchema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if schema, ok := schemaOverrides[t]; ok {
+		return schema
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Struct:
+		properties := map[string]any{}
+
+		for i := range t.NumField() {
+			field := t.Field(i)
+
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if !field.IsExported() || name == "-" {
+				continue
+			}
+
+			if name == "" {
+				name = field.Name
+			}
+
+			properties[name] = typeSchema(field.Type)
+		}
+
+		return map[string]any{"type": "object", "properties": properties}
+	default:
+		return map[string]any{}
+	}
+}
+
+// stringList is the schema of a list of strings.
+func stringList() map[string]any {
+	return map[string]any{"type": "array", "items": map[string]any{"type": "string"}}
+}
+
+// projectSchema returns the schema of the keys of a single project.
+func projectSchema() map[string]any {
+	return map[string]any{
+		"projectID": map[string]any{"type": "string", "description": "The project name, preferred over name."},
+		"name":      map[string]any{"type": "string", "description": "The project name."},
+		"services": map[string]any{
+			"type": "object",
+			"additionalProperties": map[string]any{
+				"type":        "object",
+				"description": "A docker compose service.",
+				"properties": map[string]any{
+					"octocompose": typeSchema(reflect.TypeFor[ServiceMetadata]()),
+				},
+			},
+		},
+		"repos": typeSchema(reflect.TypeFor[octoconfig.Repo]()),
+		"octoctl": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"workingDir":     map[string]any{"type": "string"},
+				"networkDriver":  map[string]any{"type": "string"},
+				"networkMTU":     map[string]any{"type": "integer"},
+				"extraHosts":     stringList(),
+				"profiles":       stringList(),
+				"composeCommand": stringList(),
+				"composeArgs":    stringList(),
+				"baseFiles":      stringList(),
+				"sudo":           map[string]any{"type": "boolean"},
+				"buildkit":       map[string]any{"type": "boolean"},
+				"sudoCommand":    stringList(),
+				"variables": map[string]any{
+					"type":                 "object",
+					"additionalProperties": map[string]any{"type": []any{"string", "number", "boolean"}},
+				},
+				"composeEnv": map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "string"}},
+				"commands":   map[string]any{"type": "object", "additionalProperties": stringList()},
+				"defaults":   typeSchema(reflect.TypeFor[CommandDefaults]()),
+			},
+		},
+	}
+}
+
+// ConfigSchema returns the JSON schema of the operator config.
+func ConfigSchema() map[string]any {
+	properties := projectSchema()
+	properties["projects"] = map[string]any{
+		"type":  "array",
+		"items": map[string]any{"type": "object", "properties": projectSchema()},
+	}
+
+	return map[string]any{
+		"$schema":    "https://json-schema.org/draft/2020-12/schema",
+		"title":      "operator-docker config",
+		"type":       "object",
+		"properties": properties,
+	}
+}
@@ -0,0 +1,210 @@
+package operatorbase
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// ReadServiceList reads newline separated service names from path,
+// empty lines and lines starting with # are skipped.
+func ReadServiceList(path string) ([]string, error) {
+	b, err := os.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("while reading the service list '%s': %w", path, err)
+	}
+
+	names := []string{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		names = append(names, line)
+	}
+
+	return names, nil
+}
+
+// dependencies returns the services svc depends on.
+func dependencies(svc map[string]any) []string {
+	switch dependsOn := svc["depends_on"].(type) {
+	case []any:
+		names := make([]string, 0, len(dependsOn))
+		for _, item := range dependsOn {
+			names = append(names, fmt.Sprint(item))
+		}
+
+		return names
+	case map[string]any:
+		names := make([]string, 0, len(dependsOn))
+		for name := range dependsOn {
+			names = append(names, name)
+		}
+
+		return names
+	default:
+		return nil
+	}
+}
+
+// withDependencies returns the selected services and everything they depend on.
+func withDependencies(services map[string]any, selected []string) map[string]bool {
+	keep := map[string]bool{}
+	queue := append([]string{}, selected...)
+
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+
+		if keep[name] {
+			continue
+		}
+
+		keep[name] = true
+
+		if svc, ok := services[name].(map[string]any); ok {
+			queue = append(queue, dependencies(svc)...)
+		}
+	}
+
+	return keep
+}
+
+// selectServices keeps only the selected services and everything they depend on.
+func selectServices(services map[string]any, selected []string) {
+	keep := withDependencies(services, selected)
+
+	for name := range services {
+		if !keep[name] {
+			delete(services, name)
+		}
+	}
+}
+
+// CheckServiceNames returns an error if one of names isn't a service of the compose file at composeFilePath.
+func CheckServiceNames(composeFilePath string, names []string) error {
+	data, err := LoadComposeFile(composeFilePath)
+	if err != nil {
+		return err
+	}
+
+	services, _ := data["services"].(map[string]any) //nolint:errcheck
+
+	for _, name := range names {
+		if _, ok := services[name]; !ok {
+			return fmt.Errorf("unknown service '%s'", name)
+		}
+	}
+
+	return nil
+}
+
+// ServiceConfig returns the definition of service in the compose file at composeFilePath.
+func ServiceConfig(composeFilePath, service string) (map[string]any, error) {
+	data, err := LoadComposeFile(composeFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	services, _ := data["services"].(map[string]any) //nolint:errcheck
+
+	svc, ok := services[service].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("unknown service '%s'", service)
+	}
+
+	return svc, nil
+}
+
+// ServiceDependencies returns the sorted services of the compose file at composeFilePath
+// the selected services depend on, directly or indirectly, without the selected ones.
+func ServiceDependencies(composeFilePath string, selected []string) ([]string, error) {
+	data, err := LoadComposeFile(composeFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	services, _ := data["services"].(map[string]any) //nolint:errcheck
+
+	for _, name := range selected {
+		if _, ok := services[name]; !ok {
+			return nil, fmt.Errorf("unknown service '%s'", name)
+		}
+	}
+
+	deps := []string{}
+
+	for name := range withDependencies(services, selected) {
+		if !slices.Contains(selected, name) {
+			deps = append(deps, name)
+		}
+	}
+
+	slices.Sort(deps)
+
+	return deps, nil
+}
+
+// DependencyTiers orders services into tiers, every service only depends on
+// services of earlier tiers. The services of a tier are sorted by name.
+// It returns an error if the dependencies have a cycle.
+func DependencyTiers(services map[string]any) ([][]string, error) {
+	remaining := map[string][]string{}
+
+	for name, svc := range services {
+		svcMap, _ := svc.(map[string]any) //nolint:errcheck
+
+		deps := []string{}
+
+		for _, dep := range dependencies(svcMap) {
+			// Dependencies outside of the file can't be ordered.
+			if _, ok := services[dep]; ok {
+				deps = append(deps, dep)
+			}
+		}
+
+		remaining[name] = deps
+	}
+
+	done := map[string]bool{}
+	tiers := [][]string{}
+
+	for len(remaining) > 0 {
+		tier := []string{}
+
+		for name, deps := range remaining {
+			if !slices.ContainsFunc(deps, func(dep string) bool { return !done[dep] }) {
+				tier = append(tier, name)
+			}
+		}
+
+		if len(tier) == 0 {
+			names := make([]string, 0, len(remaining))
+			for name := range remaining {
+				names = append(names, name)
+			}
+
+			slices.Sort(names)
+
+			return nil, fmt.Errorf("dependency cycle between %s", strings.Join(names, ", "))
+		}
+
+		slices.Sort(tier)
+
+		for _, name := range tier {
+			done[name] = true
+			delete(remaining, name)
+		}
+
+		tiers = append(tiers, tier)
+	}
+
+	return tiers, nil
+}
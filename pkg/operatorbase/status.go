@@ -0,0 +1,73 @@
+package operatorbase
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-orb/go-orb/codecs"
+)
+
+// Status is the outcome of a command as written to the status file.
+type Status struct {
+	Command   string    `json:"command"`
+	Project   string    `json:"project,omitempty"`
+	Projects  []string  `json:"projects,omitempty"`
+	ExitCode  int       `json:"exit_code"`
+	Error     string    `json:"error,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// WriteStatusFile writes status as JSON to path, atomically by renaming
+// a temporary file in the same directory.
+func WriteStatusFile(path string, status Status) error {
+	codec, err := codecs.GetMime(codecs.MimeJSON)
+	if err != nil {
+		return fmt.Errorf("while getting codec: %w", err)
+	}
+
+	b, err := codec.Marshal(status)
+	if err != nil {
+		return fmt.Errorf("while marshalling the status: %w", err)
+	}
+
+	if err := writeFileAtomic(path, append(b, '\n'), 0600); err != nil {
+		return fmt.Errorf("while writing the status file: %w", err)
+	}
+
+	return nil
+}
+
+// writeFileAtomic writes b to path with the given mode by renaming a temporary
+// file in the same directory, so readers never see a partial file.
+func writeFileAtomic(path string, b []byte, mode os.FileMode) error {
+	fp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+
+	if _, err := fp.Write(b); err != nil {
+		_ = fp.Close()
+		_ = os.Remove(fp.Name())
+
+		return err
+	}
+
+	if err := fp.Close(); err != nil {
+		_ = os.Remove(fp.Name())
+		return err
+	}
+
+	if err := os.Chmod(fp.Name(), mode); err != nil {
+		_ = os.Remove(fp.Name())
+		return err
+	}
+
+	if err := os.Rename(fp.Name(), path); err != nil {
+		_ = os.Remove(fp.Name())
+		return err
+	}
+
+	return nil
+}
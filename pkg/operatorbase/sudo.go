@@ -0,0 +1,108 @@
+package operatorbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/go-orb/go-orb/log"
+	"github.com/urfave/cli/v3"
+)
+
+// SudoCommandKey holds the privilege escalation command child processes are prefixed with.
+type SudoCommandKey struct{}
+
+// sudoCommand returns the privilege escalation command when --sudo or octoctl.sudo is set, or nil.
+// The command is --sudo-command, else octoctl.sudoCommand, else sudo.
+func sudoCommand(cmd *cli.Command, data map[string]any) ([]string, error) {
+	enabled := cmd.Bool("sudo")
+
+	if !enabled {
+		raw, ok := octoctlSection(data)["sudo"]
+		if ok && raw != nil {
+			enabled, ok = raw.(bool)
+			if !ok {
+				return nil, errors.New("octoctl.sudo must be a boolean")
+			}
+		}
+	}
+
+	if !enabled {
+		return nil, nil
+	}
+
+	command := strings.Fields(cmd.String("sudo-command"))
+
+	if len(command) == 0 {
+		configured, err := OctoctlStringList(data, "sudoCommand")
+		if err != nil {
+			return nil, err
+		}
+
+		command = configured
+	}
+
+	if len(command) == 0 {
+		command = []string{"sudo"}
+	}
+
+	if _, err := exec.LookPath(command[0]); err != nil {
+		return nil, fmt.Errorf("%s not found; is it installed and on PATH? (privilege escalation command: %s)",
+			command[0], strings.Join(command, " "))
+	}
+
+	return command, nil
+}
+
+//nolint:gochecknoglobals
+var sudoEnvWarning sync.Once
+
+// withSudo prefixes args with the privilege escalation command of ctx.
+//
+// sudo and run0 reset the environment, the extra environment of ctx is kept by
+// naming the variables with --preserve-env and --setenv, their values never show up
+// in the process list. Other commands get it through env(1) as arguments.
+func withSudo(ctx context.Context, args []string) []string {
+	sudo, _ := ctx.Value(SudoCommandKey{}).([]string) //nolint:errcheck
+	if len(sudo) == 0 {
+		return args
+	}
+
+	result := append([]string{}, sudo...)
+
+	env := childEnv(ctx)
+	if len(env) == 0 {
+		return append(result, args...)
+	}
+
+	names := make([]string, 0, len(env))
+	for _, pair := range env {
+		name, _, _ := strings.Cut(pair, "=")
+		names = append(names, name)
+	}
+
+	switch filepath.Base(sudo[0]) {
+	case "sudo":
+		result = append(result, "--preserve-env="+strings.Join(names, ","))
+	case "run0":
+		for _, name := range names {
+			result = append(result, "--setenv="+name)
+		}
+	default:
+		sudoEnvWarning.Do(func() {
+			if logger, ok := ctx.Value(LoggerKey{}).(log.Logger); ok {
+				logger.Warn("The environment is passed to the privilege escalation command as arguments, "+
+					"its values are visible in the process list; use sudo or run0 to keep them out of it",
+					"command", sudo[0], "variables", names)
+			}
+		})
+
+		result = append(append(result, "env"), env...)
+	}
+
+	return append(result, args...)
+}
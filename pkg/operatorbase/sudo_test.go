@@ -0,0 +1,35 @@
+package operatorbase
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestWithSudo(t *testing.T) {
+	env := map[string]string{"SECRET": "hunter2", "COMPOSE_PROFILES": "web"}
+
+	tests := []struct {
+		name string
+		sudo []string
+		want []string
+	}{
+		{name: "no sudo", sudo: nil, want: []string{"docker", "ps"}},
+		{name: "sudo", sudo: []string{"sudo", "-n"}, want: []string{"sudo", "-n", "--preserve-env=COMPOSE_PROFILES,SECRET", "docker", "ps"}},
+		{name: "run0", sudo: []string{"/usr/bin/run0"}, want: []string{"/usr/bin/run0", "--setenv=COMPOSE_PROFILES", "--setenv=SECRET", "docker", "ps"}},
+		{name: "other", sudo: []string{"doas"}, want: []string{"doas", "env", "COMPOSE_PROFILES=web", "SECRET=hunter2", "docker", "ps"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.WithValue(testContext(t), EnvKey{}, env)
+			if tt.sudo != nil {
+				ctx = context.WithValue(ctx, SudoCommandKey{}, tt.sudo)
+			}
+
+			if got := withSudo(ctx, []string{"docker", "ps"}); !slices.Equal(got, tt.want) {
+				t.Errorf("withSudo() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
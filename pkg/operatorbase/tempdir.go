@@ -0,0 +1,41 @@
+package operatorbase
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+//nolint:gochecknoglobals
+var (
+	tempDirsMu sync.Mutex
+	tempDirs   []string
+)
+
+// tempComposeDir creates a temporary directory for the compose file of projectID,
+// it's removed by Cleanup.
+func tempComposeDir(projectID string) (string, error) {
+	dir, err := os.MkdirTemp("", "octocompose-"+projectID+"-")
+	if err != nil {
+		return "", fmt.Errorf("while creating a temporary directory: %w", err)
+	}
+
+	tempDirsMu.Lock()
+	tempDirs = append(tempDirs, dir)
+	tempDirsMu.Unlock()
+
+	return dir, nil
+}
+
+// Cleanup removes the temporary files written with --no-cache-write,
+// call it once the command has finished.
+func Cleanup() {
+	tempDirsMu.Lock()
+	defer tempDirsMu.Unlock()
+
+	for _, dir := range tempDirs {
+		_ = os.RemoveAll(dir)
+	}
+
+	tempDirs = nil
+}
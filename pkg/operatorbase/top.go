@@ -0,0 +1,125 @@
+package operatorbase
+
+import (
+	"context"
+	"strings"
+)
+
+// Process is a process of a container as listed by docker compose top,
+// Columns maps the column headers (UID, PID, CMD, ...) to their values.
+type Process struct {
+	Container string            `json:"container"`
+	Columns   map[string]string `json:"columns"`
+}
+
+// ParseTop parses the text output of docker compose top into the processes of each container.
+//
+// Before compose 2.24 the output has a block per container: the container name,
+// a header line and a line per process. Newer versions print a single table whose
+// first columns are SERVICE and # (the replica), its processes are keyed by service.
+// The last column (CMD) may contain spaces.
+func ParseTop(out string) map[string][]Process {
+	out = strings.TrimSpace(out)
+
+	lines := strings.Split(out, "\n")
+	if headers := strings.Fields(lines[0]); len(headers) > 1 && headers[0] == "SERVICE" && headers[1] == "#" {
+		return parseTopTable(headers, lines[1:])
+	}
+
+	result := map[string][]Process{}
+
+	for _, block := range strings.Split(out, "\n\n") {
+		lines := strings.Split(strings.TrimSpace(block), "\n")
+		if len(lines) < 2 {
+			continue
+		}
+
+		container := strings.TrimSpace(lines[0])
+		headers := strings.Fields(lines[1])
+		processes := []Process{}
+
+		for _, line := range lines[2:] {
+			columns, ok := topColumns(headers, line)
+			if !ok {
+				continue
+			}
+
+			processes = append(processes, Process{Container: container, Columns: columns})
+		}
+
+		result[container] = processes
+	}
+
+	return result
+}
+
+// parseTopTable parses the single table printed by compose 2.24 and newer.
+func parseTopTable(headers []string, lines []string) map[string][]Process {
+	result := map[string][]Process{}
+
+	for _, line := range lines {
+		columns, ok := topColumns(headers, line)
+		if !ok {
+			continue
+		}
+
+		service := columns["SERVICE"]
+		result[service] = append(result[service], Process{Container: service, Columns: columns})
+	}
+
+	return result
+}
+
+// topColumns maps the fields of a process line to headers, the last column gets the remaining fields.
+func topColumns(headers []string, line string) (map[string]string, bool) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil, false
+	}
+
+	columns := map[string]string{}
+
+	for i, header := range headers {
+		switch {
+		case i >= len(fields):
+			columns[header] = ""
+		case i == len(headers)-1:
+			columns[header] = strings.Join(fields[i:], " ")
+		default:
+			columns[header] = fields[i]
+		}
+	}
+
+	return columns, true
+}
+
+// TopByService runs docker compose top and returns the processes grouped by service.
+func TopByService(ctx context.Context) (map[string][]Process, error) {
+	out, err := ComposeOutput(ctx, []string{"top"})
+	if err != nil {
+		return nil, err
+	}
+
+	containers, err := ListContainers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	services := map[string]string{}
+	for _, c := range containers {
+		services[c.Name] = c.Service
+	}
+
+	result := map[string][]Process{}
+
+	for container, processes := range ParseTop(string(out)) {
+		service, ok := services[container]
+		if !ok {
+			service = container
+		}
+
+		result[service] = append(result[service], processes...)
+	}
+
+	return result, nil
+}
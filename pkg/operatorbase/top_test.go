@@ -0,0 +1,68 @@
+package operatorbase
+
+import (
+	"testing"
+)
+
+func TestParseTop(t *testing.T) {
+	tests := []struct {
+		name string
+		out  string
+		want map[string][]map[string]string
+	}{
+		{
+			name: "block per container",
+			out: `app-web-1
+UID    PID     PPID    C    STIME   TTY   TIME       CMD
+root   1234    1200    0    10:00   ?     00:00:00   nginx: master process nginx -g daemon off;
+
+app-db-1
+UID    PID     PPID    C    STIME   TTY   TIME       CMD
+999    2345    2300    0    10:00   ?     00:00:01   postgres
+`,
+			want: map[string][]map[string]string{
+				"app-web-1": {{"UID": "root", "PID": "1234", "CMD": "nginx: master process nginx -g daemon off;"}},
+				"app-db-1":  {{"UID": "999", "PID": "2345", "CMD": "postgres"}},
+			},
+		},
+		{
+			name: "single table",
+			out: `SERVICE   #   UID    PID     PPID    C    STIME   TTY   TIME       CMD
+web       1   root   1234    1200    0    10:00   ?     00:00:00   nginx: master process
+web       2   root   1334    1300    0    10:00   ?     00:00:00   nginx: master process
+db        1   999    2345    2300    0    10:00   ?     00:00:01   postgres
+`,
+			want: map[string][]map[string]string{
+				"web": {
+					{"#": "1", "UID": "root", "PID": "1234", "CMD": "nginx: master process"},
+					{"#": "2", "UID": "root", "PID": "1334", "CMD": "nginx: master process"},
+				},
+				"db": {{"#": "1", "UID": "999", "PID": "2345", "CMD": "postgres"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseTop(tt.out)
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseTop() returned %d containers, want %d: %v", len(got), len(tt.want), got)
+			}
+
+			for container, want := range tt.want {
+				processes := got[container]
+				if len(processes) != len(want) {
+					t.Fatalf("ParseTop()[%s] has %d processes, want %d", container, len(processes), len(want))
+				}
+
+				for i, columns := range want {
+					for header, value := range columns {
+						if processes[i].Columns[header] != value {
+							t.Errorf("ParseTop()[%s][%d][%s] = %q, want %q", container, i, header, processes[i].Columns[header], value)
+						}
+					}
+				}
+			}
+		})
+	}
+}
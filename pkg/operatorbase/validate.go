@@ -0,0 +1,70 @@
+package operatorbase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-orb/go-orb/log"
+	"github.com/urfave/cli/v3"
+)
+
+// ValidateConfig reads and prepares the config of every project like BeforeConfig,
+// without writing a compose file or running docker. It returns all errors found.
+func ValidateConfig(ctx context.Context, logger log.Logger, cmd *cli.Command) []error {
+	data, err := ReadConfig(ctx, logger, cmd)
+	if err != nil {
+		return []error{stageError(StageReadConfig, err)}
+	}
+
+	errs := []error{}
+
+	for _, key := range []string{"composeCommand", "composeArgs", "profiles"} {
+		if _, err := OctoctlStringList(data, key); err != nil {
+			errs = append(errs, stageError(StagePrepare, err))
+		}
+	}
+
+	if _, err := OctoctlStringMap(data, "composeEnv"); err != nil {
+		errs = append(errs, stageError(StagePrepare, err))
+	}
+
+	if _, err := ParseCommandDefaults(data); err != nil {
+		errs = append(errs, stageError(StagePrepare, err))
+	}
+
+	projectConfigs, err := SplitProjects(data)
+	if err != nil {
+		return append(errs, stageError(StagePrepare, err))
+	}
+
+	for i, projectConfig := range projectConfigs {
+		name, ok := projectName(projectConfig)
+		if !ok {
+			errs = append(errs, stageError(StagePrepare, fmt.Errorf("project %d has no name", i)))
+			continue
+		}
+
+		projectLogger := logger.With("project", name)
+
+		if _, err := Variables(projectConfig); err != nil {
+			errs = append(errs, fmt.Errorf("project '%s': %w", name, stageError(StagePrepare, err)))
+			continue
+		}
+
+		if _, err := BaseFiles(cmd, projectConfig); err != nil {
+			errs = append(errs, fmt.Errorf("project '%s': %w", name, stageError(StagePrepare, err)))
+		}
+
+		repo, err := LoadRepo(projectConfig)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("project '%s': %w", name, stageError(StagePrepare, err)))
+			continue
+		}
+
+		if _, err := PrepareConfig(projectLogger, cmd, projectConfig, repo); err != nil {
+			errs = append(errs, fmt.Errorf("project '%s': %w", name, stageError(StagePrepare, err)))
+		}
+	}
+
+	return errs
+}
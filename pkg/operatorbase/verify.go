@@ -0,0 +1,72 @@
+package operatorbase
+
+import (
+	"context"
+	"io"
+	"maps"
+	"slices"
+
+	"github.com/go-orb/go-orb/log"
+)
+
+// UnresolvedImage is an image reference that couldn't be resolved in its registry.
+type UnresolvedImage struct {
+	Service string
+	Image   string
+}
+
+// ServiceImages returns the image of every service of the rendered compose file
+// without a build section, by service name.
+func ServiceImages(composeFilePath string) (map[string]string, error) {
+	data, err := LoadComposeFile(composeFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	services, _ := data["services"].(map[string]any) //nolint:errcheck
+	images := map[string]string{}
+
+	for name, svc := range services {
+		svcMap, ok := svc.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		// Built images don't have to exist in a registry.
+		if _, ok := svcMap["build"]; ok {
+			continue
+		}
+
+		if image, ok := svcMap["image"].(string); ok && image != "" {
+			images[name] = image
+		}
+	}
+
+	return images, nil
+}
+
+// VerifyImages checks with docker manifest inspect that the image of every service
+// exists in its registry, using the credentials of docker login.
+func VerifyImages(ctx context.Context) ([]UnresolvedImage, error) {
+	logger := ctx.Value(LoggerKey{}).(log.Logger)
+
+	images, err := ServiceImages(ComposeFilePath(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep the manifests out of the output.
+	ctx = context.WithValue(ctx, StdoutKey{}, io.Discard)
+
+	unresolved := []UnresolvedImage{}
+
+	for _, name := range slices.Sorted(maps.Keys(images)) {
+		logger.Debug("Verifying image", "service", name, "image", images[name])
+
+		if err := RunDocker(ctx, []string{"manifest", "inspect", images[name]}); err != nil {
+			unresolved = append(unresolved, UnresolvedImage{Service: name, Image: images[name]})
+		}
+	}
+
+	return unresolved, nil
+}
@@ -0,0 +1,153 @@
+package operatorbase
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-orb/go-orb/log"
+)
+
+// ComposeVersion is a parsed docker compose version.
+type ComposeVersion struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// Less reports whether v is older than other.
+func (v ComposeVersion) Less(other ComposeVersion) bool {
+	if v.Major != other.Major {
+		return v.Major < other.Major
+	}
+
+	if v.Minor != other.Minor {
+		return v.Minor < other.Minor
+	}
+
+	return v.Patch < other.Patch
+}
+
+func (v ComposeVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// flagMinVersions lists the docker compose version the flags the operator emits require.
+//
+//nolint:gochecknoglobals
+var flagMinVersions = map[string]ComposeVersion{
+	"--dry-run":   {Major: 2, Minor: 19},
+	"--wait":      {Major: 2, Minor: 1, Patch: 1},
+	"--hash":      {Major: 2},
+	"--no-attach": {Major: 2, Minor: 20},
+}
+
+//nolint:gochecknoglobals
+var (
+	composeVersionsMu sync.Mutex
+	composeVersions   = map[string]ComposeVersion{}
+)
+
+// ParseComposeVersion parses the output of docker compose version --short,
+// like "2.24.5", "v2.24.5" or "2.24.5-desktop.1".
+func ParseComposeVersion(out string) (ComposeVersion, error) {
+	str := strings.TrimPrefix(strings.TrimSpace(out), "v")
+	str, _, _ = strings.Cut(str, "-")
+	str, _, _ = strings.Cut(str, "+")
+
+	parts := strings.Split(str, ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return ComposeVersion{}, fmt.Errorf("invalid compose version '%s'", strings.TrimSpace(out))
+	}
+
+	numbers := make([]int, 3)
+
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return ComposeVersion{}, fmt.Errorf("invalid compose version '%s'", strings.TrimSpace(out))
+		}
+
+		numbers[i] = n
+	}
+
+	return ComposeVersion{Major: numbers[0], Minor: numbers[1], Patch: numbers[2]}, nil
+}
+
+// DetectComposeVersion runs the compose command of ctx with version --short,
+// the result is cached for the process.
+func DetectComposeVersion(ctx context.Context) (ComposeVersion, error) {
+	composeCommand := ctx.Value(ComposeCommandKey{}).([]string)
+	key := strings.Join(composeCommand, " ")
+
+	composeVersionsMu.Lock()
+	defer composeVersionsMu.Unlock()
+
+	if version, ok := composeVersions[key]; ok {
+		return version, nil
+	}
+
+	var buf bytes.Buffer
+
+	args := append(append([]string{}, composeCommand...), "version", "--short")
+	if err := RunCmd(context.WithValue(ctx, StdoutKey{}, &buf), args); err != nil {
+		return ComposeVersion{}, err
+	}
+
+	version, err := ParseComposeVersion(buf.String())
+	if err != nil {
+		return ComposeVersion{}, err
+	}
+
+	composeVersions[key] = version
+
+	return version, nil
+}
+
+// checkComposeVersion returns an error if args contain a flag the installed
+// docker compose is too old for. It only applies to the docker backend.
+func checkComposeVersion(ctx context.Context, args []string) error {
+	composeCommand := ctx.Value(ComposeCommandKey{}).([]string)
+	if DetectBackend(composeCommand) != BackendDocker {
+		return nil
+	}
+
+	var (
+		required ComposeVersion
+		flags    []string
+	)
+
+	for _, flag := range commandFlags(args) {
+		if minVersion, ok := flagMinVersions[flag]; ok {
+			flags = append(flags, flag)
+
+			if required.Less(minVersion) {
+				required = minVersion
+			}
+		}
+	}
+
+	if len(flags) == 0 {
+		return nil
+	}
+
+	version, err := DetectComposeVersion(ctx)
+	if err != nil {
+		// Let compose report the problem itself.
+		logger := ctx.Value(LoggerKey{}).(log.Logger)
+		logger.Debug("Unable to detect the compose version", "error", err)
+
+		return nil
+	}
+
+	for _, flag := range flags {
+		if minVersion := flagMinVersions[flag]; version.Less(minVersion) {
+			return fmt.Errorf("%s requires docker compose >= %s, found %s", flag, minVersion, version)
+		}
+	}
+
+	return nil
+}
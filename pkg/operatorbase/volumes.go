@@ -0,0 +1,192 @@
+package operatorbase
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-orb/go-orb/log"
+)
+
+// prefixVolumes prefixes the names of the top level volumes with prefix and
+// rewrites the service references to them, external volumes are left as is.
+func prefixVolumes(data map[string]any, services map[string]any, prefix string) error {
+	if prefix == "" {
+		return nil
+	}
+
+	volumes, ok := data["volumes"].(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	renamed := map[string]string{}
+
+	for name, volume := range volumes {
+		if v, ok := volume.(map[string]any); ok {
+			if external, ok := v["external"].(bool); ok && external {
+				continue
+			}
+		}
+
+		renamed[name] = prefix + name
+	}
+
+	for name, newName := range renamed {
+		volumes[newName] = volumes[name]
+		delete(volumes, name)
+	}
+
+	for svcName, svcValue := range services {
+		svc, ok := svcValue.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		mounts, ok := svc["volumes"].([]any)
+		if !ok {
+			continue
+		}
+
+		for i, mount := range mounts {
+			switch m := mount.(type) {
+			case string:
+				source, rest, found := strings.Cut(m, ":")
+				if newName, ok := renamed[source]; ok && found {
+					mounts[i] = newName + ":" + rest
+				}
+			case map[string]any:
+				source, _ := m["source"].(string) //nolint:errcheck
+				if newName, ok := renamed[source]; ok && (m["type"] == nil || m["type"] == "volume") {
+					m["source"] = newName
+				}
+			default:
+				return fmt.Errorf("service '%s' has an invalid volume entry %v", svcName, mount)
+			}
+		}
+	}
+
+	return nil
+}
+
+// volumeSource returns the named volume a volumes entry of a service mounts, if any.
+func volumeSource(mount any, volumes map[string]any) (string, bool) {
+	var source string
+
+	switch mount := mount.(type) {
+	case string:
+		// Short syntax, a single path is an anonymous volume.
+		before, _, ok := strings.Cut(mount, ":")
+		if !ok {
+			return "", false
+		}
+
+		source = before
+	case map[string]any:
+		if mount["type"] != nil && mount["type"] != "volume" {
+			return "", false
+		}
+
+		source, _ = mount["source"].(string) //nolint:errcheck
+	}
+
+	// Bind mounts aren't declared as top level volumes.
+	if _, ok := volumes[source]; !ok || source == "" {
+		return "", false
+	}
+
+	return source, true
+}
+
+// ServiceVolumes returns the docker names of the named volumes service mounts in the
+// compose file at composeFilePath of project. It returns an error for external volumes
+// and volumes other services mount too.
+func ServiceVolumes(composeFilePath, project, service string) ([]string, error) {
+	data, err := LoadComposeFile(composeFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	services, _ := data["services"].(map[string]any) //nolint:errcheck
+	volumes, _ := data["volumes"].(map[string]any)   //nolint:errcheck
+
+	svc, ok := services[service].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("unknown service '%s'", service)
+	}
+
+	mounts, _ := svc["volumes"].([]any) //nolint:errcheck
+
+	sources := []string{}
+
+	for _, mount := range mounts {
+		if source, ok := volumeSource(mount, volumes); ok && !slices.Contains(sources, source) {
+			sources = append(sources, source)
+		}
+	}
+
+	for name, other := range services {
+		if name == service {
+			continue
+		}
+
+		otherMap, _ := other.(map[string]any)         //nolint:errcheck
+		otherMounts, _ := otherMap["volumes"].([]any) //nolint:errcheck
+
+		for _, mount := range otherMounts {
+			if source, ok := volumeSource(mount, volumes); ok && slices.Contains(sources, source) {
+				return nil, fmt.Errorf("volume '%s' is shared with service '%s'", source, name)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(sources))
+
+	for _, source := range sources {
+		volume, _ := volumes[source].(map[string]any) //nolint:errcheck
+
+		if external, _ := volume["external"].(bool); external { //nolint:errcheck
+			return nil, fmt.Errorf("volume '%s' is external", source)
+		}
+
+		if name, ok := volume["name"].(string); ok && name != "" {
+			names = append(names, name)
+		} else {
+			names = append(names, composeProjectName(project)+"_"+source)
+		}
+	}
+
+	return names, nil
+}
+
+// ResetServiceVolumes stops service, removes its anonymous and named volumes and starts it again.
+func ResetServiceVolumes(ctx context.Context, service string) error {
+	logger := ctx.Value(LoggerKey{}).(log.Logger)
+
+	volumes, err := ServiceVolumes(ComposeFilePath(ctx), CurrentProject(ctx).Name, service)
+	if err != nil {
+		logger.Error("Error while resolving the volumes", "service", service, "error", err)
+		return err
+	}
+
+	logger.Info("Resetting volumes", "service", service, "volumes", volumes)
+
+	if err := RunCompose(ctx, []string{"stop", service}); err != nil {
+		return err
+	}
+
+	// rm -v removes the anonymous volumes of the container.
+	if err := RunCompose(ctx, []string{"rm", "--force", "-v", service}); err != nil {
+		return err
+	}
+
+	if len(volumes) > 0 {
+		if err := RunDocker(ctx, append([]string{"volume", "rm"}, volumes...)); err != nil {
+			logger.Error("Error while removing the volumes", "service", service, "error", err)
+			return fmt.Errorf("while removing the volumes of '%s': %w", service, err)
+		}
+	}
+
+	return RunCompose(ctx, []string{"up", "-d", service})
+}
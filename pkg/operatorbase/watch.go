@@ -0,0 +1,64 @@
+package operatorbase
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// fileStamp identifies a version of a file.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func statFile(path string) (fileStamp, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}, fmt.Errorf("while checking '%s': %w", path, err)
+	}
+
+	return fileStamp{modTime: info.ModTime(), size: info.Size()}, nil
+}
+
+// WatchFile polls path every interval and calls onChange once the file
+// didn't change for debounce. It returns when ctx is done.
+// Polling instead of fsnotify also catches changes on network filesystems.
+func WatchFile(ctx context.Context, path string, interval, debounce time.Duration, onChange func()) error {
+	last, err := statFile(path)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var changedAt time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			current, err := statFile(path)
+			if err != nil {
+				// Editors may replace the file, try again on the next tick.
+				continue
+			}
+
+			if current != last {
+				last = current
+				changedAt = now
+
+				continue
+			}
+
+			if !changedAt.IsZero() && now.Sub(changedAt) >= debounce {
+				changedAt = time.Time{}
+
+				onChange()
+			}
+		}
+	}
+}